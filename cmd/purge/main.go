@@ -0,0 +1,56 @@
+// Command purge permanently deletes archived products and categories that are
+// older than their retention period.
+//
+//	go run ./cmd/purge -product-days 365 -category-days 365
+package main
+
+import (
+	"flag"
+	"gocats/internal/config"
+	"gocats/internal/database"
+	"gocats/internal/repository"
+	"gocats/internal/services"
+	"gocats/internal/storage"
+	"log"
+	"time"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	productDays := flag.Int("product-days", cfg.Purge.ProductRetentionDays, "days to keep archived products")
+	categoryDays := flag.Int("category-days", cfg.Purge.CategoryRetentionDays, "days to keep archived categories")
+	flag.Parse()
+
+	db, err := database.New(database.Config{
+		DSN: cfg.Database.DSN,
+	})
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	defer db.Close()
+
+	fileStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalf("Error setting up storage: %v", err)
+	}
+
+	purgeService := services.NewPurgeService(
+		repository.NewProductRepository(db.DB),
+		repository.NewCategoryRepository(db.DB),
+		fileStorage,
+	)
+
+	result, err := purgeService.Purge(services.PurgeRules{
+		ProductRetention:  time.Duration(*productDays) * 24 * time.Hour,
+		CategoryRetention: time.Duration(*categoryDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		log.Fatalf("Purge failed: %v", err)
+	}
+
+	log.Printf("🗑️  Purged %d products and %d categories", result.ProductsPurged, result.CategoriesPurged)
+}
@@ -0,0 +1,56 @@
+// Package auth verifies bearer tokens and checks the permissions of the
+// signed in user on every API route.
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidToken is returned when a bearer token is malformed, expired or
+// not signed by a trusted key
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Identity is the signed in user of a request
+type Identity struct {
+	UserID   uint   `json:"user_id,omitempty"`
+	Subject  string `json:"subject"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// Verifier turns a bearer token into the identity it was issued to
+type Verifier interface {
+	Verify(token string) (*Identity, error)
+}
+
+// Verifiers tries each verifier in turn and returns the first identity. A
+// verifier rejecting a token it recognises, e.g. of a deactivated user, stops
+// the search.
+type Verifiers []Verifier
+
+func (vs Verifiers) Verify(token string) (*Identity, error) {
+	for _, v := range vs {
+		identity, err := v.Verify(token)
+		if err == nil {
+			return identity, nil
+		}
+		if !errors.Is(err, ErrInvalidToken) {
+			return nil, err
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the signed in user
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the signed in user of ctx, if any
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
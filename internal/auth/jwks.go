@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// jwksMinRefresh limits how often the key set is fetched again, after a
+// success or a failure, so tokens with made up key IDs or a key server that is
+// down cannot flood it
+const jwksMinRefresh = 30 * time.Second
+
+// JWKS is a JSON Web Key Set fetched from a URL and cached. The set is fetched
+// again when the cache expires or a token names a key ID not in the set, which
+// picks up rotated keys without a restart.
+type JWKS struct {
+	url        string
+	ttl        time.Duration
+	minRefresh time.Duration
+	client     *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	// attemptedAt is the start of the last fetch and err its failure
+	attemptedAt time.Time
+	err         error
+	// fetching is closed when the fetch in progress ends, nil when idle
+	fetching chan struct{}
+}
+
+// jwk is one key of a key set, only RSA and EC signing keys are used
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func NewJWKS(url string, ttl time.Duration) *JWKS {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &JWKS{url: url, ttl: ttl, minRefresh: jwksMinRefresh, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Key returns the public key with key ID kid. The key set is fetched without
+// holding the lock; concurrent callers wait for the fetch in progress.
+func (j *JWKS) Key(kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	for {
+		key, found := j.keys[kid]
+		if found && time.Since(j.fetchedAt) < j.ttl {
+			j.mu.Unlock()
+			return key, nil
+		}
+
+		if wait := j.fetching; wait != nil {
+			j.mu.Unlock()
+			<-wait
+			j.mu.Lock()
+			continue
+		}
+
+		// Refetch an expired set, or a fresh one missing the key after a
+		// rotation, at most once every minRefresh
+		if time.Since(j.attemptedAt) < j.minRefresh {
+			err := j.err
+			if j.keys != nil {
+				err = fmt.Errorf("unknown signing key %q", kid)
+			}
+			j.mu.Unlock()
+			// Keep verifying with the cached keys while the key server is down
+			if found {
+				return key, nil
+			}
+			return nil, err
+		}
+
+		done := make(chan struct{})
+		j.fetching = done
+		j.attemptedAt = time.Now()
+		j.mu.Unlock()
+
+		keys, err := j.fetch()
+
+		j.mu.Lock()
+		j.err = err
+		if err == nil {
+			j.keys = keys
+			j.fetchedAt = time.Now()
+		}
+		j.fetching = nil
+		close(done)
+	}
+}
+
+// fetch downloads the key set and returns its usable signing keys
+func (j *JWKS) fetch() (map[string]crypto.PublicKey, error) {
+	resp, err := j.client.Get(j.url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		var validate ecdh.Curve
+		switch k.Crv {
+		case "P-256":
+			curve, validate = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, validate = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, validate = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		// Reject points off the curve, ecdh checks the uncompressed encoding
+		size := (curve.Params().BitSize + 7) / 8
+		point := make([]byte, 1+2*size)
+		point[0] = 4
+		if x.BitLen() > size*8 || y.BitLen() > size*8 {
+			return nil, errors.New("invalid EC point")
+		}
+		x.FillBytes(point[1 : 1+size])
+		y.FillBytes(point[1+size:])
+		if _, err := validate.NewPublicKey(point); err != nil {
+			return nil, errors.New("invalid EC point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Policy maps routes to the permission they need. Routes use the
+// net/http.ServeMux pattern syntax, e.g. "PUT /api/products/{id}", and the
+// most specific pattern matching a request wins.
+type Policy struct {
+	mux   *http.ServeMux
+	rules map[string]Permission
+}
+
+func NewPolicy(rules map[string]Permission) *Policy {
+	policy := &Policy{mux: http.NewServeMux(), rules: rules}
+	for pattern := range rules {
+		policy.mux.Handle(pattern, http.NotFoundHandler())
+	}
+	return policy
+}
+
+// Permission returns the permission needed by a request, false when no route
+// of the policy matches it
+func (p *Policy) Permission(r *http.Request) (Permission, bool) {
+	_, pattern := p.mux.Handler(r)
+	permission, ok := p.rules[pattern]
+	return permission, ok
+}
+
+// Middleware authenticates the bearer token of every request with verifier and
+// rejects it unless the role of the user has the permission the policy needs
+// for the route. Requests to routes missing from the policy are rejected.
+func Middleware(verifier Verifier, policy *Policy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		permission, ok := policy.Permission(r)
+		if ok && permission == Public {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, found := bearerToken(r)
+		if !found {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gocats"`)
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+
+		identity, err := verifier.Verify(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gocats", error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		if !ok || !Can(identity.Role, permission) {
+			writeError(w, http.StatusForbidden, "permission denied")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+	})
+}
+
+// bearerToken returns the token of the Authorization: Bearer header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
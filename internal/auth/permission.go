@@ -0,0 +1,72 @@
+package auth
+
+import "gocats/internal/models"
+
+// Permission is an action a role may take on the API
+type Permission string
+
+const (
+	// Public routes need no token
+	Public Permission = "public"
+	// Authenticated routes need a valid token of any role
+	Authenticated Permission = "authenticated"
+
+	CatalogRead       Permission = "catalog:read"
+	CatalogWrite      Permission = "catalog:write"
+	CustomersRead     Permission = "customers:read"
+	CustomersWrite    Permission = "customers:write"
+	CustomersDelete   Permission = "customers:delete"
+	Sell              Permission = "sales:sell"
+	TransactionsRead  Permission = "transactions:read"
+	Refund            Permission = "transactions:refund"
+	StoredValueManage Permission = "stored-value:manage"
+	ShiftsOperate     Permission = "shifts:operate"
+	ShiftsManage      Permission = "shifts:manage"
+	ReportsRead       Permission = "reports:read"
+	ReportsClose      Permission = "reports:close"
+	UsersManage       Permission = "users:manage"
+)
+
+// viewerPermissions only read the catalog, customers and sales
+var viewerPermissions = []Permission{CatalogRead, CustomersRead, TransactionsRead}
+
+// cashierPermissions work the till: sell, keep customers and run their own shift
+var cashierPermissions = append([]Permission{CustomersWrite, Sell, ShiftsOperate}, viewerPermissions...)
+
+// managerPermissions change the catalog and prices, refund, and see reports
+var managerPermissions = append([]Permission{
+	CatalogWrite, CustomersDelete, Refund, StoredValueManage, ShiftsManage, ReportsRead, ReportsClose,
+}, cashierPermissions...)
+
+// rolePermissions are the permissions granted to each role, admins get all
+var rolePermissions = map[string]map[Permission]bool{
+	models.RoleViewer:  permissionSet(viewerPermissions),
+	models.RoleCashier: permissionSet(cashierPermissions),
+	models.RoleManager: permissionSet(managerPermissions),
+	models.RoleAdmin:   permissionSet(append([]Permission{UsersManage}, managerPermissions...)),
+}
+
+func permissionSet(permissions []Permission) map[Permission]bool {
+	set := make(map[Permission]bool, len(permissions))
+	for _, p := range permissions {
+		set[p] = true
+	}
+	return set
+}
+
+// ValidRole reports whether role is one of the user roles
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Can reports whether role is granted permission
+func Can(role string, permission Permission) bool {
+	switch permission {
+	case Public:
+		return true
+	case Authenticated:
+		return ValidRole(role)
+	}
+	return rolePermissions[role][permission]
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"gocats/internal/models"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SupabaseConfig sets how access tokens issued by Supabase Auth are verified
+// and how their claims map to user roles
+type SupabaseConfig struct {
+	// URL is the project URL, e.g. https://abcd.supabase.co. Tokens must be
+	// issued by its /auth/v1 endpoint and its JWKS is used unless JWKSURL is set.
+	URL string
+	// JWTSecret verifies HS256 tokens of projects using the legacy shared secret
+	JWTSecret string
+	// JWKSURL serves the public keys of RS256 and ES256 tokens
+	JWKSURL      string
+	JWKSCacheTTL time.Duration
+	Audience     string
+	// RoleClaim is the dotted path of the claim holding the role, e.g.
+	// app_metadata.role; the claim may be a string or a list of strings
+	RoleClaim string
+	// RoleMap renames claim values to roles, e.g. owner to admin
+	RoleMap map[string]string
+	// DefaultRole is given to users without a role claim, "" refuses them
+	DefaultRole string
+}
+
+// Enabled reports whether Supabase tokens are accepted
+func (c SupabaseConfig) Enabled() bool {
+	return c.URL != "" || c.JWTSecret != "" || c.JWKSURL != ""
+}
+
+// SupabaseVerifier verifies access tokens issued by Supabase Auth
+type SupabaseVerifier struct {
+	config  SupabaseConfig
+	issuer  string
+	jwks    *JWKS
+	methods []string
+}
+
+func NewSupabaseVerifier(config SupabaseConfig) (*SupabaseVerifier, error) {
+	if config.DefaultRole != "" && !ValidRole(config.DefaultRole) {
+		return nil, fmt.Errorf("unknown default role %q", config.DefaultRole)
+	}
+	for from, to := range config.RoleMap {
+		if !ValidRole(to) {
+			return nil, fmt.Errorf("role map %s=%s: unknown role %q", from, to, to)
+		}
+	}
+	if config.RoleClaim == "" {
+		config.RoleClaim = "app_metadata.role"
+	}
+	if config.Audience == "" {
+		config.Audience = "authenticated"
+	}
+
+	v := &SupabaseVerifier{}
+	if url := strings.TrimRight(config.URL, "/"); url != "" {
+		v.issuer = url + "/auth/v1"
+		if config.JWKSURL == "" {
+			config.JWKSURL = v.issuer + "/.well-known/jwks.json"
+		}
+	}
+	if config.JWTSecret != "" {
+		v.methods = append(v.methods, "HS256")
+	}
+	if config.JWKSURL != "" {
+		v.jwks = NewJWKS(config.JWKSURL, config.JWKSCacheTTL)
+		v.methods = append(v.methods, "RS256", "ES256")
+	}
+	v.config = config
+	return v, nil
+}
+
+// Verify checks the signature, expiry, audience and issuer of a Supabase
+// access token and returns its user with the role from the role claim. Users
+// without a role are returned with an empty role and refused every route.
+func (v *SupabaseVerifier) Verify(token string) (*Identity, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(v.methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithAudience(v.config.Audience),
+	}
+	if v.issuer != "" {
+		options = append(options, jwt.WithIssuer(v.issuer))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, v.key, options...)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, ErrInvalidToken
+	}
+
+	username := subject
+	if email, ok := claims["email"].(string); ok && email != "" {
+		username = email
+	}
+	return &Identity{Subject: subject, Username: username, Role: v.role(claims)}, nil
+}
+
+// key returns the key verifying a token: the project secret for HS256, else
+// the JWKS key named by the kid header
+func (v *SupabaseVerifier) key(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() == "HS256" {
+		return []byte(v.config.JWTSecret), nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no key ID")
+	}
+	return v.jwks.Key(kid)
+}
+
+// role returns the most privileged role named by the role claim, or the
+// default role when the claim names none
+func (v *SupabaseVerifier) role(claims jwt.MapClaims) string {
+	var value interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(v.config.RoleClaim, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			value = nil
+			break
+		}
+		value = object[part]
+	}
+
+	var names []string
+	switch value := value.(type) {
+	case string:
+		names = []string{value}
+	case []interface{}:
+		for _, item := range value {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	// models.Roles runs from most to least privileged
+	for _, role := range models.Roles {
+		for _, name := range names {
+			if mapped, ok := v.config.RoleMap[name]; ok {
+				name = mapped
+			}
+			if name == role {
+				return role
+			}
+		}
+	}
+	return v.config.DefaultRole
+}
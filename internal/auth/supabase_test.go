@@ -0,0 +1,317 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksServer serves a key set that tests can rotate and counts its fetches
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int32
+
+	mu     sync.Mutex
+	keys   []map[string]string
+	status int
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/auth/v1/.well-known/jwks.json" {
+			http.NotFound(w, r)
+			return
+		}
+		s.fetches.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.status != http.StatusOK {
+			w.WriteHeader(s.status)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kid": kid, "kty": "RSA", "use": "sig", "alg": "RS256",
+		"n": encodeBigInt(key.N), "e": encodeBigInt(big.NewInt(int64(key.E))),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kid": kid, "kty": "EC", "use": "sig", "alg": "ES256", "crv": "P-256",
+		"x": encodeBigInt(key.X), "y": encodeBigInt(key.Y),
+	}
+}
+
+// supabaseClaims are valid claims of a project at url for user 42
+func supabaseClaims(url string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   url + "/auth/v1",
+		"aud":   "authenticated",
+		"sub":   "42",
+		"email": "ana@example.com",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"app_metadata": map[string]interface{}{
+			"role": "cashier",
+		},
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestSupabaseVerifyHS256(t *testing.T) {
+	url := "https://abcd.supabase.co"
+	v, err := NewSupabaseVerifier(SupabaseConfig{URL: url, JWTSecret: "project-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := v.Verify(sign(t, jwt.SigningMethodHS256, "", []byte("project-secret"), supabaseClaims(url)))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if identity.Subject != "42" || identity.Username != "ana@example.com" || identity.Role != "cashier" {
+		t.Errorf("identity = %+v", identity)
+	}
+
+	if _, err := v.Verify(sign(t, jwt.SigningMethodHS256, "", []byte("other-secret"), supabaseClaims(url))); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token signed with another secret: %v", err)
+	}
+}
+
+func TestSupabaseVerifyJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newJWKSServer(t)
+	server.setKeys(rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey))
+
+	v, err := NewSupabaseVerifier(SupabaseConfig{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		method jwt.SigningMethod
+		kid    string
+		key    interface{}
+	}{
+		{jwt.SigningMethodRS256, "rsa-1", rsaKey},
+		{jwt.SigningMethodES256, "ec-1", ecKey},
+	} {
+		identity, err := v.Verify(sign(t, tc.method, tc.kid, tc.key, supabaseClaims(server.URL)))
+		if err != nil {
+			t.Errorf("%s: Verify: %v", tc.method.Alg(), err)
+			continue
+		}
+		if identity.Subject != "42" {
+			t.Errorf("%s: identity = %+v", tc.method.Alg(), identity)
+		}
+	}
+	if n := server.fetches.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+
+	// A key named by the wrong key ID does not verify
+	if _, err := v.Verify(sign(t, jwt.SigningMethodRS256, "ec-1", rsaKey, supabaseClaims(server.URL))); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("RS256 token with an EC key ID: %v", err)
+	}
+	// Tokens need a key ID
+	if _, err := v.Verify(sign(t, jwt.SigningMethodRS256, "", rsaKey, supabaseClaims(server.URL))); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token without a key ID: %v", err)
+	}
+}
+
+func TestSupabaseUnknownKeyRefreshesJWKS(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newJWKSServer(t)
+	server.setKeys(rsaJWK("old", oldKey))
+
+	v, err := NewSupabaseVerifier(SupabaseConfig{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(sign(t, jwt.SigningMethodRS256, "old", oldKey, supabaseClaims(server.URL))); err != nil {
+		t.Fatalf("Verify with the old key: %v", err)
+	}
+
+	// The key rotates right after the first fetch: the refetch waits for minRefresh
+	server.setKeys(rsaJWK("old", oldKey), ecJWK("new", newKey))
+	rotated := sign(t, jwt.SigningMethodES256, "new", newKey, supabaseClaims(server.URL))
+	if _, err := v.Verify(rotated); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify within minRefresh of the last fetch: %v", err)
+	}
+	if n := server.fetches.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times within minRefresh, want 1", n)
+	}
+
+	v.jwks.mu.Lock()
+	v.jwks.minRefresh = 0
+	v.jwks.mu.Unlock()
+	if _, err := v.Verify(rotated); err != nil {
+		t.Fatalf("Verify with the rotated key: %v", err)
+	}
+	if n := server.fetches.Load(); n != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", n)
+	}
+}
+
+func TestJWKSRateLimitsFailedFetches(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newJWKSServer(t)
+	server.setStatus(http.StatusInternalServerError)
+	jwks := NewJWKS(server.URL+"/auth/v1/.well-known/jwks.json", time.Minute)
+
+	// Concurrent callers share one fetch and later ones wait for minRefresh
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := jwks.Key("rsa-1"); err == nil {
+				t.Error("Key succeeded while the key server fails")
+			}
+		}()
+	}
+	wg.Wait()
+	if n := server.fetches.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times after failures, want 1", n)
+	}
+
+	server.setStatus(http.StatusOK)
+	server.setKeys(rsaJWK("rsa-1", key))
+	jwks.mu.Lock()
+	jwks.minRefresh = 0
+	jwks.mu.Unlock()
+	if _, err := jwks.Key("rsa-1"); err != nil {
+		t.Errorf("Key after the key server recovered: %v", err)
+	}
+}
+
+func TestSupabaseRejectsInvalidClaims(t *testing.T) {
+	url := "https://abcd.supabase.co"
+	v, err := NewSupabaseVerifier(SupabaseConfig{URL: url, JWTSecret: "project-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, change := range map[string]func(jwt.MapClaims){
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"no expiry":      func(c jwt.MapClaims) { delete(c, "exp") },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://other.supabase.co/auth/v1" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "anon" },
+		"no subject":     func(c jwt.MapClaims) { delete(c, "sub") },
+	} {
+		claims := supabaseClaims(url)
+		change(claims)
+		if _, err := v.Verify(sign(t, jwt.SigningMethodHS256, "", []byte("project-secret"), claims)); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Verify = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestSupabaseRoleClaim(t *testing.T) {
+	url := "https://abcd.supabase.co"
+	v, err := NewSupabaseVerifier(SupabaseConfig{
+		URL:         url,
+		JWTSecret:   "project-secret",
+		RoleClaim:   "app_metadata.roles",
+		RoleMap:     map[string]string{"owner": "admin", "staff": "cashier"},
+		DefaultRole: "viewer",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		roles interface{}
+		want  string
+	}{
+		{"mapped string", "staff", "cashier"},
+		{"most privileged of a list", []interface{}{"staff", "owner", "manager"}, "admin"},
+		{"role name", "manager", "manager"},
+		{"unknown role", "intern", "viewer"},
+		{"no claim", nil, "viewer"},
+	} {
+		claims := supabaseClaims(url)
+		claims["app_metadata"] = map[string]interface{}{}
+		if tc.roles != nil {
+			claims["app_metadata"] = map[string]interface{}{"roles": tc.roles}
+		}
+		identity, err := v.Verify(sign(t, jwt.SigningMethodHS256, "", []byte("project-secret"), claims))
+		if err != nil {
+			t.Errorf("%s: Verify: %v", tc.name, err)
+			continue
+		}
+		if identity.Role != tc.want {
+			t.Errorf("%s: role = %q, want %q", tc.name, identity.Role, tc.want)
+		}
+	}
+
+	if _, err := NewSupabaseVerifier(SupabaseConfig{RoleMap: map[string]string{"owner": "root"}}); err == nil {
+		t.Error("role map to an unknown role accepted")
+	}
+}
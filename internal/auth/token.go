@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenIssuer is the iss claim of the tokens signed by this API
+const tokenIssuer = "gocats"
+
+// TokenConfig sets the HS256 signing secret and lifetime of login tokens
+type TokenConfig struct {
+	Secret string
+	TTL    time.Duration
+}
+
+// Tokens signs and verifies the JWTs issued at login
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// claims are the JWT claims of a login token, sub is the user ID
+type claims struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+func NewTokens(config TokenConfig) (*Tokens, error) {
+	if len(config.Secret) < 32 {
+		return nil, errors.New("JWT secret must be at least 32 characters")
+	}
+	if config.TTL <= 0 {
+		config.TTL = 12 * time.Hour
+	}
+	return &Tokens{secret: []byte(config.Secret), ttl: config.TTL}, nil
+}
+
+// Issue signs a token for the user that expires after the configured lifetime
+func (t *Tokens) Issue(userID uint, username, role string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.ttl)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+
+	signed, err := token.SignedString(t.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// Verify checks the signature, issuer and expiry of a login token and returns
+// the identity it was issued to
+func (t *Tokens) Verify(token string) (*Identity, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(*jwt.Token) (interface{}, error) {
+		return t.secret, nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithIssuer(tokenIssuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	userID, err := strconv.ParseUint(c.Subject, 10, 32)
+	if err != nil || userID == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	return &Identity{UserID: uint(userID), Subject: c.Subject, Username: c.Username, Role: c.Role}, nil
+}
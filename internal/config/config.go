@@ -2,7 +2,12 @@ package config
 
 import (
 	"fmt"
+	"gocats/internal/auth"
+	"gocats/internal/receipt"
+	"gocats/internal/storage"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -10,6 +15,15 @@ import (
 type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
+	Storage  storage.Config
+	Purge    PurgeConfig
+	Loyalty  LoyaltyConfig
+	Tax      TaxConfig
+	Receipt  receipt.Config
+	Printer  receipt.PrinterConfig
+	Invoice  InvoiceConfig
+	Shift    ShiftConfig
+	Auth     AuthConfig
 }
 
 type ServerConfig struct {
@@ -21,6 +35,47 @@ type DatabaseConfig struct {
 	DSN string
 }
 
+type PurgeConfig struct {
+	ProductRetentionDays  int
+	CategoryRetentionDays int
+}
+
+// LoyaltyConfig sets the points earned per currency unit paid and the value of
+// a point when redeemed
+type LoyaltyConfig struct {
+	EarnRate   float64
+	PointValue float64
+}
+
+// TaxConfig sets the tax rate in percent included in product prices
+type TaxConfig struct {
+	Rate float64
+}
+
+// InvoiceConfig sets the format of invoice numbers: prefix, store code, date
+// and a sequence of Digits digits restarting daily or yearly
+type InvoiceConfig struct {
+	Prefix    string
+	StoreCode string
+	Reset     string
+	Digits    int
+	Separator string
+}
+
+// ShiftConfig sets whether checkouts and refunds need an open cashier shift
+type ShiftConfig struct {
+	Required bool
+}
+
+// AuthConfig sets how login tokens are signed, the first admin created on an
+// empty database and the Supabase Auth tokens accepted besides login tokens
+type AuthConfig struct {
+	Token         auth.TokenConfig
+	AdminUsername string
+	AdminPassword string
+	Supabase      auth.SupabaseConfig
+}
+
 func Load() (*Config, error) {
 	// Load .env file (KEY=VALUE) using viper without external libs
 	viper.SetConfigFile(".env")
@@ -40,6 +95,135 @@ func Load() (*Config, error) {
 		Database: DatabaseConfig{
 			DSN: viper.GetString("DATABASE_URL"),
 		},
+		Storage: storage.Config{
+			Driver:    viper.GetString("STORAGE_DRIVER"),
+			LocalDir:  viper.GetString("STORAGE_LOCAL_DIR"),
+			PublicURL: viper.GetString("STORAGE_PUBLIC_URL"),
+			S3: storage.S3Config{
+				Endpoint:  viper.GetString("S3_ENDPOINT"),
+				Bucket:    viper.GetString("S3_BUCKET"),
+				Region:    viper.GetString("S3_REGION"),
+				AccessKey: viper.GetString("S3_ACCESS_KEY"),
+				SecretKey: viper.GetString("S3_SECRET_KEY"),
+				PublicURL: viper.GetString("S3_PUBLIC_URL"),
+			},
+		},
+		Purge: PurgeConfig{
+			ProductRetentionDays:  viper.GetInt("PURGE_PRODUCT_RETENTION_DAYS"),
+			CategoryRetentionDays: viper.GetInt("PURGE_CATEGORY_RETENTION_DAYS"),
+		},
+		Loyalty: LoyaltyConfig{
+			EarnRate:   viper.GetFloat64("LOYALTY_EARN_RATE"),
+			PointValue: viper.GetFloat64("LOYALTY_POINT_VALUE"),
+		},
+		Tax: TaxConfig{
+			Rate: viper.GetFloat64("TAX_RATE"),
+		},
+		Receipt: receipt.Config{
+			Store: receipt.Store{
+				Name:    viper.GetString("STORE_NAME"),
+				Address: viper.GetString("STORE_ADDRESS"),
+				Phone:   viper.GetString("STORE_PHONE"),
+				TaxID:   viper.GetString("STORE_TAX_ID"),
+			},
+			Footer:      viper.GetString("RECEIPT_FOOTER"),
+			TemplateDir: viper.GetString("RECEIPT_TEMPLATE_DIR"),
+		},
+		Printer: receipt.PrinterConfig{
+			Address: viper.GetString("PRINTER_ADDRESS"),
+			Timeout: time.Duration(viper.GetInt("PRINTER_TIMEOUT_SECONDS")) * time.Second,
+		},
+		Invoice: InvoiceConfig{
+			Prefix:    viper.GetString("INVOICE_PREFIX"),
+			StoreCode: viper.GetString("INVOICE_STORE_CODE"),
+			Reset:     viper.GetString("INVOICE_RESET"),
+			Digits:    viper.GetInt("INVOICE_DIGITS"),
+			Separator: viper.GetString("INVOICE_SEPARATOR"),
+		},
+		Shift: ShiftConfig{
+			Required: viper.GetBool("SHIFT_REQUIRED"),
+		},
+		Auth: AuthConfig{
+			Token: auth.TokenConfig{
+				Secret: viper.GetString("JWT_SECRET"),
+				TTL:    time.Duration(viper.GetInt("JWT_TTL_HOURS")) * time.Hour,
+			},
+			AdminUsername: viper.GetString("ADMIN_USERNAME"),
+			AdminPassword: viper.GetString("ADMIN_PASSWORD"),
+			Supabase: auth.SupabaseConfig{
+				URL:          viper.GetString("SUPABASE_URL"),
+				JWTSecret:    viper.GetString("SUPABASE_JWT_SECRET"),
+				JWKSURL:      viper.GetString("SUPABASE_JWKS_URL"),
+				JWKSCacheTTL: time.Duration(viper.GetInt("SUPABASE_JWKS_CACHE_MINUTES")) * time.Minute,
+				Audience:     viper.GetString("SUPABASE_JWT_AUDIENCE"),
+				RoleClaim:    viper.GetString("SUPABASE_ROLE_CLAIM"),
+				DefaultRole:  viper.GetString("SUPABASE_DEFAULT_ROLE"),
+			},
+		},
+	}
+
+	// Keep archived items for a year unless configured otherwise
+	if !viper.IsSet("PURGE_PRODUCT_RETENTION_DAYS") {
+		config.Purge.ProductRetentionDays = 365
+	}
+	if !viper.IsSet("PURGE_CATEGORY_RETENTION_DAYS") {
+		config.Purge.CategoryRetentionDays = 365
+	}
+
+	// One point per 1000 spent, worth 10 when redeemed, unless configured otherwise
+	if !viper.IsSet("LOYALTY_EARN_RATE") {
+		config.Loyalty.EarnRate = 0.001
+	}
+	if !viper.IsSet("LOYALTY_POINT_VALUE") {
+		config.Loyalty.PointValue = 10
+	}
+
+	if !viper.IsSet("STORE_NAME") {
+		config.Receipt.Store.Name = "Gocats"
+	}
+	if !viper.IsSet("RECEIPT_FOOTER") {
+		config.Receipt.Footer = "Thank you for shopping with us"
+	}
+
+	if !viper.IsSet("PRINTER_TIMEOUT_SECONDS") {
+		config.Printer.Timeout = 5 * time.Second
+	}
+
+	// INV-20261019-00001 unless configured otherwise
+	if !viper.IsSet("INVOICE_PREFIX") {
+		config.Invoice.Prefix = "INV"
+	}
+	if config.Invoice.Reset == "" {
+		config.Invoice.Reset = "daily"
+	}
+	if !viper.IsSet("INVOICE_DIGITS") {
+		config.Invoice.Digits = 5
+	}
+	if !viper.IsSet("INVOICE_SEPARATOR") {
+		config.Invoice.Separator = "-"
+	}
+	if config.Invoice.Reset != "daily" && config.Invoice.Reset != "yearly" {
+		return nil, fmt.Errorf("INVOICE_RESET must be daily or yearly")
+	}
+	if config.Invoice.Digits < 1 || config.Invoice.Digits > 12 {
+		return nil, fmt.Errorf("INVOICE_DIGITS must be between 1 and 12")
+	}
+
+	// Login tokens last a working day unless configured otherwise
+	if !viper.IsSet("JWT_TTL_HOURS") {
+		config.Auth.Token.TTL = 12 * time.Hour
+	}
+
+	// SUPABASE_ROLE_MAP renames role claim values, e.g. owner=admin,staff=cashier
+	if roleMap := viper.GetString("SUPABASE_ROLE_MAP"); roleMap != "" {
+		config.Auth.Supabase.RoleMap = map[string]string{}
+		for _, pair := range strings.Split(roleMap, ",") {
+			from, to, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("SUPABASE_ROLE_MAP must be a list of claim=role pairs")
+			}
+			config.Auth.Supabase.RoleMap[strings.TrimSpace(from)] = strings.TrimSpace(to)
+		}
 	}
 
 	if config.Database.DSN == "" {
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"gocats/internal/auth"
+	"net/http"
+	"strings"
+)
+
+// actorHeader names the person making a change when the request carries no
+// signed in user
+const actorHeader = "X-Actor"
+
+// requestActor returns who made the request: the signed in user, else the
+// X-Actor header, or "" when unknown
+func requestActor(r *http.Request) string {
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		return identity.Username
+	}
+	return strings.TrimSpace(r.Header.Get(actorHeader))
+}
+
+// requestCan reports whether the signed in user has the permission
+func requestCan(r *http.Request, permission auth.Permission) bool {
+	identity, ok := auth.FromContext(r.Context())
+	return ok && auth.Can(identity.Role, permission)
+}
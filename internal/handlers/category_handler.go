@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"gocats/internal/models"
 	"gocats/internal/services"
 	"net/http"
 	"strconv"
@@ -19,11 +21,24 @@ func NewCategoryHandler(service services.CategoryService) *CategoryHandler {
 type CreateCategoryRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	ParentID    *uint  `json:"parent_id"`
 }
 
 type UpdateCategoryRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	// ParentID moves the category, 0 makes it a top-level category
+	ParentID *uint `json:"parent_id"`
+}
+
+type MergeCategoriesRequest struct {
+	SourceIDs []uint `json:"source_ids"`
+	// DeleteSources removes the source categories permanently instead of archiving them
+	DeleteSources bool `json:"delete_sources"`
+}
+
+type SetAttributeSchemaRequest struct {
+	Attributes models.AttributeSchema `json:"attributes"`
 }
 
 func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
@@ -34,7 +49,7 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	category, err := h.service.CreateCategory(req.Name, req.Description)
+	category, err := h.service.CreateCategory(req.Name, req.Description, req.ParentID)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -47,15 +62,26 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *CategoryHandler) GetAllCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.service.GetAllCategories()
+	if r.URL.Query().Get("archived") == "true" {
+		h.GetArchivedCategories(w, r)
+		return
+	}
+
+	opts, err := parseQueryOptions(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(categories)
+	categories, page, err := h.service.GetAllCategories(opts)
+	if err != nil {
+		w.WriteHeader(listErrorStatus(err, http.StatusInternalServerError))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeList(w, r, categories, page)
 }
 
 func (h *CategoryHandler) GetCategoryByID(w http.ResponseWriter, r *http.Request) {
@@ -94,7 +120,7 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	category, err := h.service.UpdateCategory(uint(id), req.Name, req.Description)
+	category, err := h.service.UpdateCategory(uint(id), req.Name, req.Description, req.ParentID)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -105,6 +131,9 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(category)
 }
 
+// DeleteCategory archives a category. Products are handled by the query options
+// reassign_to={category_id} or cascade=archive; without them the request fails
+// with 409 while the category still has products.
 func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/categories/")
 	id, err := strconv.ParseUint(path, 10, 32)
@@ -114,12 +143,186 @@ func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.service.DeleteCategory(uint(id)); err != nil {
+	var opts services.DeleteCategoryOptions
+	if reassignTo := r.URL.Query().Get("reassign_to"); reassignTo != "" {
+		target, err := strconv.ParseUint(reassignTo, 10, 32)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid reassign_to category ID"})
+			return
+		}
+		opts.ReassignTo = uint(target)
+	}
+	switch cascade := r.URL.Query().Get("cascade"); cascade {
+	case "":
+	case "archive":
+		opts.ArchiveProducts = true
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "cascade must be \"archive\""})
+		return
+	}
+
+	result, err := h.service.DeleteCategory(uint(id), opts)
+	if err != nil {
+		var inUse *services.CategoryInUseError
+		if errors.As(err, &inUse) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":             err.Error(),
+				"active_products":   inUse.ActiveProducts,
+				"archived_products": inUse.ArchivedProducts,
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":             "success archiving a category",
+		"products_reassigned": result.ProductsReassigned,
+		"products_archived":   result.ProductsArchived,
+	})
+}
+
+func (h *CategoryHandler) SetAttributeSchema(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/categories/"), "/attributes")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid category ID"})
+		return
+	}
+
+	var req SetAttributeSchemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	category, err := h.service.SetAttributeSchema(uint(id), req.Attributes)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+func (h *CategoryHandler) GetArchivedCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.service.GetArchivedCategories()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+func (h *CategoryHandler) RestoreCategory(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/categories/"), "/restore")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid category ID"})
+		return
+	}
+
+	category, err := h.service.RestoreCategory(uint(id))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+func (h *CategoryHandler) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.service.GetCategoryTree()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+func (h *CategoryHandler) GetCategorySubtree(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/categories/"), "/tree")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid category ID"})
+		return
+	}
+
+	tree, err := h.service.GetCategorySubtree(uint(id))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+func (h *CategoryHandler) MergeCategories(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/categories/"), "/merge")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid category ID"})
+		return
+	}
+
+	var req MergeCategoriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	merge, err := h.service.MergeCategories(uint(id), req.SourceIDs, req.DeleteSources)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(merge)
+}
+
+func (h *CategoryHandler) GetCategoryMerges(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/categories/"), "/merge")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid category ID"})
+		return
+	}
+
+	merges, err := h.service.GetCategoryMerges(uint(id))
+	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "success deleting a category"})
+	json.NewEncoder(w).Encode(merges)
 }
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gocats/internal/models"
+	"gocats/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CustomerGroupHandler struct {
+	service services.CustomerGroupService
+}
+
+func NewCustomerGroupHandler(service services.CustomerGroupService) *CustomerGroupHandler {
+	return &CustomerGroupHandler{service: service}
+}
+
+type CustomerGroupRequest struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Tiers       []models.PriceTier `json:"tiers"`
+}
+
+func (h *CustomerGroupHandler) CreateCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	var req CustomerGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	group, err := h.service.CreateCustomerGroup(req.Name, req.Description, req.Tiers)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+func (h *CustomerGroupHandler) GetCustomerGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.service.GetCustomerGroups()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+func (h *CustomerGroupHandler) GetCustomerGroupByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/customer-groups/"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid customer group ID"})
+		return
+	}
+
+	group, err := h.service.GetCustomerGroupByID(uint(id))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+func (h *CustomerGroupHandler) UpdateCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/customer-groups/"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid customer group ID"})
+		return
+	}
+
+	var req CustomerGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	group, err := h.service.UpdateCustomerGroup(uint(id), req.Name, req.Description, req.Tiers)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+func (h *CustomerGroupHandler) DeleteCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/customer-groups/"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid customer group ID"})
+		return
+	}
+
+	if err := h.service.DeleteCustomerGroup(uint(id)); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "success deleting a customer group"})
+}
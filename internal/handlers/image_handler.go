@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gocats/internal/services"
+	"io"
+	"net/http"
+)
+
+// maxImageSize is the largest accepted image upload in bytes
+const maxImageSize = 10 << 20
+
+type ImageHandler struct {
+	service services.ImageService
+}
+
+func NewImageHandler(service services.ImageService) *ImageHandler {
+	return &ImageHandler{service: service}
+}
+
+type ReorderImagesRequest struct {
+	ImageIDs []uint `json:"image_ids"`
+}
+
+// UploadImage accepts a multipart form with the photo in the "image" field
+func (h *ImageHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	productID, _, err := parseProductSubPath(r.URL.Path, "images")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageSize+1<<20)
+	if err := r.ParseMultipartForm(maxImageSize); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid multipart form or image too large"})
+		return
+	}
+
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "image file is required"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxImageSize {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		json.NewEncoder(w).Encode(map[string]string{"error": "image too large"})
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to read image"})
+		return
+	}
+
+	image, err := h.service.UploadImage(productID, data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(image)
+}
+
+func (h *ImageHandler) GetImages(w http.ResponseWriter, r *http.Request) {
+	productID, _, err := parseProductSubPath(r.URL.Path, "images")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	images, err := h.service.GetImages(productID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(images)
+}
+
+func (h *ImageHandler) ReorderImages(w http.ResponseWriter, r *http.Request) {
+	productID, _, err := parseProductSubPath(r.URL.Path, "images")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	var req ReorderImagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	images, err := h.service.ReorderImages(productID, req.ImageIDs)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(images)
+}
+
+func (h *ImageHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	productID, imageID, err := parseProductSubPath(r.URL.Path, "images")
+	if err != nil || imageID == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid image ID"})
+		return
+	}
+
+	if err := h.service.DeleteImage(productID, imageID); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "success deleting an image"})
+}
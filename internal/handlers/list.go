@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gocats/internal/repository"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// parseQueryOptions reads limit, offset, cursor, sort and order query parameters.
+// sort accepts a leading "-" for descending order, e.g. sort=-price. Without
+// any of limit, offset or cursor all rows are returned, as before pagination;
+// paging without a limit uses the default page size.
+func parseQueryOptions(r *http.Request) (repository.QueryOptions, error) {
+	query := r.URL.Query()
+	opts := repository.QueryOptions{}
+	if query.Get("offset") != "" || query.Get("cursor") != "" {
+		opts.Limit = repository.DefaultLimit
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return opts, errors.New("limit must be a positive number")
+		}
+		opts.Limit = min(n, repository.MaxLimit)
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return opts, errors.New("offset must be zero or a positive number")
+		}
+		opts.Offset = n
+	}
+
+	opts.Cursor = query.Get("cursor")
+
+	opts.Sort = query.Get("sort")
+	if strings.HasPrefix(opts.Sort, "-") {
+		opts.Sort = strings.TrimPrefix(opts.Sort, "-")
+		opts.Desc = true
+	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return opts, errors.New("order must be asc or desc")
+	}
+
+	return opts, nil
+}
+
+// listErrorStatus maps invalid query options to 400 and other errors to fallback
+func listErrorStatus(err error, fallback int) int {
+	if errors.Is(err, repository.ErrInvalidQueryOptions) {
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
+// writeList writes a page of items as JSON with the X-Total-Count, X-Next-Cursor
+// and Link headers. When the fields query parameter is set only those fields are returned.
+func writeList(w http.ResponseWriter, r *http.Request, items interface{}, page *repository.Page) {
+	writePageHeaders(w, r, page)
+
+	var body interface{} = items
+	if fields := r.URL.Query().Get("fields"); fields != "" {
+		selected, err := selectFields(items, strings.Split(fields, ","))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		body = selected
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// writePageHeaders sets the X-Total-Count, X-Next-Cursor and Link headers of a page
+func writePageHeaders(w http.ResponseWriter, r *http.Request, page *repository.Page) {
+	if page == nil {
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(page.Total, 10))
+	if links := pageLinks(r.URL, page); links != "" {
+		w.Header().Set("Link", links)
+	}
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+}
+
+// pageLinks builds an RFC 8288 Link header with first, prev and next relations
+func pageLinks(u *url.URL, page *repository.Page) string {
+	if page.Limit <= 0 {
+		return ""
+	}
+
+	link := func(rel string, set map[string]string) string {
+		q := u.Query()
+		q.Del("cursor")
+		q.Del("offset")
+		for k, v := range set {
+			q.Set(k, v)
+		}
+		next := *u
+		next.RawQuery = q.Encode()
+		return fmt.Sprintf(`<%s>; rel="%s"`, next.RequestURI(), rel)
+	}
+
+	links := []string{link("first", nil)}
+
+	if u.Query().Get("cursor") == "" && page.Offset > 0 {
+		prev := max(page.Offset-page.Limit, 0)
+		links = append(links, link("prev", map[string]string{"offset": strconv.Itoa(prev)}))
+	}
+
+	switch {
+	case page.NextCursor != "" && u.Query().Get("cursor") != "":
+		links = append(links, link("next", map[string]string{"cursor": page.NextCursor}))
+	case u.Query().Get("cursor") == "" && int64(page.Offset+page.Limit) < page.Total:
+		links = append(links, link("next", map[string]string{"offset": strconv.Itoa(page.Offset + page.Limit)}))
+	}
+
+	return strings.Join(links, ", ")
+}
+
+// selectFields keeps only the requested top-level JSON fields of each item
+func selectFields(items interface{}, fields []string) ([]map[string]interface{}, error) {
+	b, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(b, &rows); err != nil {
+		return nil, err
+	}
+
+	for i, row := range rows {
+		selected := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			field = strings.TrimSpace(field)
+			if value, ok := row[field]; ok {
+				selected[field] = value
+			}
+		}
+		rows[i] = selected
+	}
+	return rows, nil
+}
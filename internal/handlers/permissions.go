@@ -0,0 +1,68 @@
+package handlers
+
+import "gocats/internal/auth"
+
+// RoutePermissions is the permission each route needs, keyed by
+// net/http.ServeMux pattern. Routes missing here are refused, so new routes
+// must be added when they are registered in main.go. Locally stored uploads
+// are public under the route of their public URL.
+var RoutePermissions = map[string]auth.Permission{
+	"/health":              auth.Public,
+	"POST /api/auth/login": auth.Public,
+	"GET /api/auth/me":     auth.Authenticated,
+
+	// Catalog and pricing: everyone reads, managers change products and prices
+	"GET /api/categories":       auth.CatalogRead,
+	"GET /api/categories/":      auth.CatalogRead,
+	"/api/categories":           auth.CatalogWrite,
+	"/api/categories/":          auth.CatalogWrite,
+	"GET /api/products":         auth.CatalogRead,
+	"GET /api/products/":        auth.CatalogRead,
+	"/api/products":             auth.CatalogWrite,
+	"/api/products/":            auth.CatalogWrite,
+	"GET /api/price-lists":      auth.CatalogRead,
+	"GET /api/price-lists/":     auth.CatalogRead,
+	"/api/price-lists":          auth.CatalogWrite,
+	"/api/price-lists/":         auth.CatalogWrite,
+	"GET /api/promotions":       auth.CatalogRead,
+	"GET /api/promotions/":      auth.CatalogRead,
+	"/api/promotions":           auth.CatalogWrite,
+	"/api/promotions/":          auth.CatalogWrite,
+	"GET /api/customer-groups":  auth.CatalogRead,
+	"GET /api/customer-groups/": auth.CatalogRead,
+	"/api/customer-groups":      auth.CatalogWrite,
+	"/api/customer-groups/":     auth.CatalogWrite,
+
+	// Customers, gift cards and store credit
+	"GET /api/customers":                    auth.CustomersRead,
+	"GET /api/customers/":                   auth.CustomersRead,
+	"POST /api/customers":                   auth.CustomersWrite,
+	"PUT /api/customers/{id}":               auth.CustomersWrite,
+	"DELETE /api/customers/{id}":            auth.CustomersDelete,
+	"POST /api/customers/{id}/store-credit": auth.StoredValueManage,
+	"POST /api/gift-cards":                  auth.StoredValueManage,
+	"GET /api/gift-cards/":                  auth.Sell,
+
+	// Sales
+	"POST /api/checkout":                 auth.Sell,
+	"POST /api/checkout/preview":         auth.Sell,
+	"GET /api/transactions":              auth.TransactionsRead,
+	"GET /api/transactions/":             auth.TransactionsRead,
+	"POST /api/transactions/{id}/print":  auth.Sell,
+	"POST /api/transactions/{id}/refund": auth.Refund,
+	"GET /api/shifts":                    auth.ShiftsManage,
+	"GET /api/shifts/":                   auth.ShiftsOperate,
+	"POST /api/shifts":                   auth.ShiftsOperate,
+	// The shift service also needs ShiftsManage for shifts of other cashiers
+	"POST /api/shifts/{id}/movements": auth.ShiftsOperate,
+	"POST /api/shifts/{id}/close":     auth.ShiftsOperate,
+
+	// Reports and closing the day
+	"GET /api/report":    auth.ReportsRead,
+	"GET /api/report/":   auth.ReportsRead,
+	"POST /api/report/z": auth.ReportsClose,
+
+	// User accounts
+	"/api/users":  auth.UsersManage,
+	"/api/users/": auth.UsersManage,
+}
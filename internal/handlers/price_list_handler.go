@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gocats/internal/models"
+	"gocats/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PriceListHandler struct {
+	service services.PriceListService
+}
+
+func NewPriceListHandler(service services.PriceListService) *PriceListHandler {
+	return &PriceListHandler{service: service}
+}
+
+type PriceListRequest struct {
+	Name     string                  `json:"name"`
+	StartsAt time.Time               `json:"starts_at"`
+	EndsAt   time.Time               `json:"ends_at"`
+	Entries  []models.PriceListEntry `json:"entries"`
+}
+
+func (h *PriceListHandler) CreatePriceList(w http.ResponseWriter, r *http.Request) {
+	var req PriceListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	priceList, err := h.service.CreatePriceList(req.Name, req.StartsAt, req.EndsAt, req.Entries)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(priceList)
+}
+
+// GetPriceLists lists price lists; active_at (date or RFC 3339) or active=true
+// narrows the list to those in effect at that time
+func (h *PriceListHandler) GetPriceLists(w http.ResponseWriter, r *http.Request) {
+	activeAt, err := parseDateParam(r.URL.Query(), "active_at", false)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if activeAt == nil && r.URL.Query().Get("active") == "true" {
+		now := time.Now()
+		activeAt = &now
+	}
+
+	priceLists, err := h.service.GetPriceLists(activeAt)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(priceLists)
+}
+
+func (h *PriceListHandler) GetPriceListByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/price-lists/"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid price list ID"})
+		return
+	}
+
+	priceList, err := h.service.GetPriceListByID(uint(id))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(priceList)
+}
+
+func (h *PriceListHandler) UpdatePriceList(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/price-lists/"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid price list ID"})
+		return
+	}
+
+	var req PriceListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	priceList, err := h.service.UpdatePriceList(uint(id), req.Name, req.StartsAt, req.EndsAt, req.Entries)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(priceList)
+}
+
+func (h *PriceListHandler) DeletePriceList(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/price-lists/"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid price list ID"})
+		return
+	}
+
+	if err := h.service.DeletePriceList(uint(id)); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "success deleting a price list"})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"gocats/internal/repository"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filterDateLayout is the plain date format accepted besides RFC 3339 for date range filters
+const filterDateLayout = "2006-01-02"
+
+// parseProductFilter maps the product list query parameters onto a ProductFilter
+func parseProductFilter(query url.Values) (repository.ProductFilter, error) {
+	filter := repository.ProductFilter{Name: query.Get("name")}
+
+	if ids := query.Get("category_id"); ids != "" {
+		for _, s := range strings.Split(ids, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+			if err != nil || id == 0 {
+				return filter, fmt.Errorf("invalid category ID %q", s)
+			}
+			filter.CategoryIDs = append(filter.CategoryIDs, uint(id))
+		}
+	}
+
+	var err error
+	if filter.MinPrice, err = parsePriceParam(query, "min_price"); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parsePriceParam(query, "max_price"); err != nil {
+		return filter, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, fmt.Errorf("min_price cannot be greater than max_price")
+	}
+
+	switch query.Get("in_stock") {
+	case "", "false":
+	case "true":
+		filter.InStock = true
+	default:
+		return filter, fmt.Errorf("in_stock must be true or false")
+	}
+
+	if lowStock := query.Get("low_stock"); lowStock != "" {
+		n, err := strconv.Atoi(lowStock)
+		if err != nil || n < 0 {
+			return filter, fmt.Errorf("low_stock must be zero or a positive number")
+		}
+		filter.LowStock = &n
+	}
+
+	dates := []struct {
+		param string
+		dest  **time.Time
+		to    bool
+	}{
+		{"created_from", &filter.CreatedFrom, false},
+		{"created_to", &filter.CreatedTo, true},
+		{"updated_from", &filter.UpdatedFrom, false},
+		{"updated_to", &filter.UpdatedTo, true},
+	}
+	for _, d := range dates {
+		if *d.dest, err = parseDateParam(query, d.param, d.to); err != nil {
+			return filter, err
+		}
+	}
+
+	for key, values := range query {
+		if strings.HasPrefix(key, attributeFilterPrefix) && len(values) > 0 {
+			if filter.Attributes == nil {
+				filter.Attributes = make(map[string]string)
+			}
+			filter.Attributes[strings.TrimPrefix(key, attributeFilterPrefix)] = values[0]
+		}
+	}
+
+	return filter, nil
+}
+
+func parsePriceParam(query url.Values, param string) (*float64, error) {
+	value := query.Get(param)
+	if value == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("%s must be zero or a positive number", param)
+	}
+	return &price, nil
+}
+
+// parseDateParam accepts YYYY-MM-DD or RFC 3339. A plain date used as the end
+// of a range includes the whole day.
+func parseDateParam(query url.Values, param string, endOfRange bool) (*time.Time, error) {
+	value := query.Get(param)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(filterDateLayout, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a date (YYYY-MM-DD) or RFC 3339 timestamp", param)
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"gocats/internal/models"
 	"gocats/internal/services"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ProductHandler struct {
@@ -17,21 +21,27 @@ func NewProductHandler(service services.ProductService) *ProductHandler {
 }
 
 type CreateProductRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Stock       int     `json:"stock"`
-	CategoryID  uint    `json:"category_id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Price       float64                `json:"price"`
+	Stock       int                    `json:"stock"`
+	CategoryID  uint                   `json:"category_id"`
+	Attributes  map[string]interface{} `json:"attributes"`
 }
 
+// UpdateProductRequest leaves a field unchanged when it is left out
 type UpdateProductRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Stock       int     `json:"stock"`
-	CategoryID  uint    `json:"category_id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Price       *float64               `json:"price"`
+	Stock       *int                   `json:"stock"`
+	CategoryID  uint                   `json:"category_id"`
+	Attributes  map[string]interface{} `json:"attributes"`
 }
 
+// attributeFilterPrefix marks query parameters that filter on custom attributes, e.g. attr.brand=Apple
+const attributeFilterPrefix = "attr."
+
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var req CreateProductRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -40,7 +50,7 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.service.CreateProduct(req.Name, req.Description, req.Price, req.Stock, req.CategoryID)
+	product, err := h.service.CreateProduct(req.Name, req.Description, req.Price, req.Stock, req.CategoryID, req.Attributes)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -53,17 +63,69 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ProductHandler) GetAllProducts(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
+	if r.URL.Query().Get("archived") == "true" {
+		h.GetArchivedProducts(w, r)
+		return
+	}
 
-	products, err := h.service.GetAllProducts(name)
+	filter, err := parseProductFilter(r.URL.Query())
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
+	includeDescendants := r.URL.Query().Get("include_descendants") == "true"
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	opts, err := parseQueryOptions(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	products, page, err := h.service.GetAllProducts(filter, includeDescendants, opts)
+	if err != nil {
+		w.WriteHeader(listErrorStatus(err, http.StatusNotFound))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeList(w, r, products, page)
+}
+
+// SearchProducts handles GET /api/products/search?q=term. The product list
+// filters can be combined with the search term.
+func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	term := r.URL.Query().Get("q")
+	if strings.TrimSpace(term) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "q query parameter is required"})
+		return
+	}
+
+	filter, err := parseProductFilter(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	includeDescendants := r.URL.Query().Get("include_descendants") == "true"
+
+	opts, err := parseQueryOptions(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	results, page, err := h.service.SearchProducts(term, filter, includeDescendants, opts)
+	if err != nil {
+		w.WriteHeader(listErrorStatus(err, http.StatusNotFound))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeList(w, r, results, page)
 }
 
 func (h *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request) {
@@ -75,7 +137,32 @@ func (h *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	product, err := h.service.GetProductByID(uint(id))
+	// at shows the price that applies at another time, e.g. during a scheduled promotion
+	atParam, err := parseDateParam(r.URL.Query(), "at", false)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	at := time.Now()
+	if atParam != nil {
+		at = *atParam
+	}
+
+	// customer_group_id adds the tier prices of a customer group
+	var groupID *uint
+	if value := r.URL.Query().Get("customer_group_id"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid customer_group_id"})
+			return
+		}
+		group := uint(parsed)
+		groupID = &group
+	}
+
+	product, err := h.service.GetProductByID(uint(id), at, groupID)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -86,33 +173,38 @@ func (h *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(product)
 }
 
-func (h *ProductHandler) GetProductsByCategoryID(w http.ResponseWriter, r *http.Request) {
-	categoryIDStr := r.URL.Query().Get("category_id")
-	if categoryIDStr == "" {
+func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/products/")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "category_id query parameter is required"})
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid product ID"})
 		return
 	}
 
-	categoryID, err := strconv.ParseUint(categoryIDStr, 10, 32)
-	if err != nil {
+	var req UpdateProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid category ID"})
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
 		return
 	}
 
-	products, err := h.service.GetProductsByCategoryID(uint(categoryID))
+	product, err := h.service.UpdateProduct(uint(id), req.Name, req.Description, req.Price, req.Stock, req.CategoryID, req.Attributes, requestActor(r))
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
+		status := http.StatusBadRequest
+		if err.Error() == "product not found" {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	json.NewEncoder(w).Encode(product)
 }
 
-func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/products/")
 	id, err := strconv.ParseUint(path, 10, 32)
 	if err != nil {
@@ -121,26 +213,246 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req UpdateProductRequest
+	if err := h.service.DeleteProduct(uint(id)); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "success archiving a product"})
+}
+
+type SaveProductOptionRequest struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type CreateVariantRequest struct {
+	SKU     string            `json:"sku"`
+	Options map[string]string `json:"options"`
+	Price   *float64          `json:"price"`
+	Stock   int               `json:"stock"`
+}
+
+type SetBundleComponentsRequest struct {
+	Components []models.BundleComponentInput `json:"components"`
+}
+
+type UpdateVariantRequest struct {
+	SKU   string   `json:"sku"`
+	Price *float64 `json:"price"`
+	Stock *int     `json:"stock"`
+}
+
+// parseProductSubPath splits /api/products/{id}/{resource}/{subID} into its IDs.
+// subID is zero when the path has no trailing ID.
+func parseProductSubPath(path, resource string) (uint, uint, error) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/products/"), "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[1] != resource {
+		return 0, 0, fmt.Errorf("invalid %s path", resource)
+	}
+
+	id, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, 0, errors.New("Invalid product ID")
+	}
+
+	var subID uint64
+	if len(parts) == 3 {
+		subID, err = strconv.ParseUint(parts[2], 10, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("Invalid %s ID", strings.TrimSuffix(resource, "s"))
+		}
+	}
+
+	return uint(id), uint(subID), nil
+}
+
+// GetPriceHistory handles GET /api/products/{id}/price-history
+func (h *ProductHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	productID, _, err := parseProductSubPath(r.URL.Path, "price-history")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	changes, err := h.service.GetPriceHistory(productID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+// GetPriceChanges handles GET /api/report/price-changes?start_date=&end_date=
+func (h *ProductHandler) GetPriceChanges(w http.ResponseWriter, r *http.Request) {
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+
+	if startDate == "" || endDate == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "start_date and end_date query parameters are required"})
+		return
+	}
+
+	changes, err := h.service.GetPriceChanges(startDate, endDate)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+func (h *ProductHandler) SaveProductOption(w http.ResponseWriter, r *http.Request) {
+	productID, _, err := parseProductSubPath(r.URL.Path, "options")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	var req SaveProductOptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	option, err := h.service.SaveProductOption(productID, req.Name, req.Values)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(option)
+}
+
+func (h *ProductHandler) AddVariant(w http.ResponseWriter, r *http.Request) {
+	productID, _, err := parseProductSubPath(r.URL.Path, "variants")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	var req CreateVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	variant, err := h.service.AddVariant(productID, req.SKU, req.Options, req.Price, req.Stock)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(variant)
+}
+
+func (h *ProductHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, err := parseProductSubPath(r.URL.Path, "variants")
+	if err != nil || variantID == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid variant ID"})
+		return
+	}
+
+	var req UpdateVariantRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
 		return
 	}
 
-	product, err := h.service.UpdateProduct(uint(id), req.Name, req.Description, req.Price, req.Stock, req.CategoryID)
+	stock := -1
+	if req.Stock != nil {
+		stock = *req.Stock
+	}
+
+	variant, err := h.service.UpdateVariant(productID, variantID, req.SKU, req.Price, stock, requestActor(r))
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(variant)
+}
+
+func (h *ProductHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, err := parseProductSubPath(r.URL.Path, "variants")
+	if err != nil || variantID == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid variant ID"})
+		return
+	}
+
+	if err := h.service.DeleteVariant(productID, variantID); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "success deleting a variant"})
+}
+
+func (h *ProductHandler) SetBundleComponents(w http.ResponseWriter, r *http.Request) {
+	productID, _, err := parseProductSubPath(r.URL.Path, "components")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	var req SetBundleComponentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	product, err := h.service.SetBundleComponents(productID, req.Components)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(product)
 }
 
-func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api/products/")
+func (h *ProductHandler) GetArchivedProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := h.service.GetArchivedProducts()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/products/"), "/restore")
 	id, err := strconv.ParseUint(path, 10, 32)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -148,12 +460,13 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.DeleteProduct(uint(id)); err != nil {
+	product, err := h.service.RestoreProduct(uint(id))
+	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "success deleting a product"})
+	json.NewEncoder(w).Encode(product)
 }
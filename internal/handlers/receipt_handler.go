@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gocats/internal/receipt"
+	"gocats/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ReceiptHandler struct {
+	service services.ReceiptService
+}
+
+func NewReceiptHandler(service services.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{service: service}
+}
+
+// GetReceipt handles GET /api/transactions/{id}/receipt. format is text
+// (default), html, pdf or escpos; paper is the roll width in mm, 58 or 80
+// (default); drawer=true kicks the cash drawer open in escpos output.
+func (h *ReceiptHandler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	id, paper, openDrawer, err := parseReceiptRequest(r, "/receipt")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	body, contentType, err := h.service.RenderReceipt(id, format, paper, openDrawer)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "transaction not found" {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	switch format {
+	case receipt.FormatPDF:
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"receipt-%d.pdf\"", id))
+	case receipt.FormatESCPOS:
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"receipt-%d.bin\"", id))
+	}
+	w.Write(body)
+}
+
+// PrintReceipt handles POST /api/transactions/{id}/print, sending the ESC/POS
+// receipt to the configured network printer. It takes the paper and drawer
+// query parameters of GetReceipt.
+func (h *ReceiptHandler) PrintReceipt(w http.ResponseWriter, r *http.Request) {
+	id, paper, openDrawer, err := parseReceiptRequest(r, "/print")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.PrintReceipt(id, paper, openDrawer); err != nil {
+		status := http.StatusBadGateway
+		switch {
+		case errors.Is(err, receipt.ErrNoPrinter):
+			status = http.StatusServiceUnavailable
+		case err.Error() == "transaction not found":
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "success printing the receipt"})
+}
+
+// parseReceiptRequest reads the transaction ID from a /api/transactions/{id}{suffix}
+// path and the paper and drawer query parameters
+func parseReceiptRequest(r *http.Request, suffix string) (uint, receipt.Paper, bool, error) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/transactions/"), suffix)
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		return 0, receipt.Paper{}, false, errors.New("Invalid transaction ID")
+	}
+
+	paper, err := parsePaper(r)
+	if err != nil {
+		return 0, receipt.Paper{}, false, err
+	}
+
+	openDrawer := false
+	if value := r.URL.Query().Get("drawer"); value != "" {
+		openDrawer, err = strconv.ParseBool(value)
+		if err != nil {
+			return 0, receipt.Paper{}, false, fmt.Errorf("invalid drawer %q", value)
+		}
+	}
+
+	return uint(id), paper, openDrawer, nil
+}
+
+// parsePaper reads the paper query parameter, 80mm when missing
+func parsePaper(r *http.Request) (receipt.Paper, error) {
+	value := r.URL.Query().Get("paper")
+	if value == "" {
+		return receipt.Paper80, nil
+	}
+	mm, err := strconv.Atoi(strings.TrimSuffix(value, "mm"))
+	if err != nil {
+		return receipt.Paper{}, fmt.Errorf("invalid paper %q", value)
+	}
+	return receipt.PaperFor(mm)
+}
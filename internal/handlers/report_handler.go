@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gocats/internal/models"
+	"gocats/internal/receipt"
+	"gocats/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ReportHandler struct {
+	service services.ReportService
+}
+
+func NewReportHandler(service services.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+// GetXReport handles GET /api/report/x, the business day so far
+func (h *ReportHandler) GetXReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetXReport()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	h.writeReport(w, r, report, http.StatusOK)
+}
+
+// CloseDay handles POST /api/report/z, storing the next Z-report
+func (h *ReportHandler) CloseDay(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.CloseDay(requestActor(r))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	h.writeReport(w, r, report, http.StatusCreated)
+}
+
+// GetAllZReports lists stored Z-reports, newest first
+func (h *ReportHandler) GetAllZReports(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseQueryOptions(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	reports, page, err := h.service.GetAllZReports(opts)
+	if err != nil {
+		w.WriteHeader(listErrorStatus(err, http.StatusInternalServerError))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeList(w, r, reports, page)
+}
+
+// GetZReport handles GET /api/report/z/{number} for reprints
+func (h *ReportHandler) GetZReport(w http.ResponseWriter, r *http.Request) {
+	number, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/report/z/"), 10, 64)
+	if err != nil || number < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid Z-report number"})
+		return
+	}
+
+	report, err := h.service.GetZReport(number)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	h.writeReport(w, r, report, http.StatusOK)
+}
+
+// writeReport writes a report as JSON, or as printable text with format=text
+// on the paper given by the paper query parameter
+func (h *ReportHandler) writeReport(w http.ResponseWriter, r *http.Request, report *models.DayReport, status int) {
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	case receipt.FormatText:
+		paper, err := parsePaper(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write(h.service.PrintDayReport(report, paper))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "format must be json or text"})
+	}
+}
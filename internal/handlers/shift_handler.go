@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gocats/internal/auth"
+	"gocats/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ShiftHandler struct {
+	service services.ShiftService
+}
+
+func NewShiftHandler(service services.ShiftService) *ShiftHandler {
+	return &ShiftHandler{service: service}
+}
+
+type OpenShiftRequest struct {
+	OpeningFloat float64 `json:"opening_float"`
+	Note         string  `json:"note"`
+}
+
+type CashMovementRequest struct {
+	// Type is pay_in or pay_out
+	Type   string  `json:"type"`
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash float64 `json:"counted_cash"`
+	Note        string  `json:"note"`
+}
+
+// OpenShift opens a shift for the signed in cashier
+func (h *ShiftHandler) OpenShift(w http.ResponseWriter, r *http.Request) {
+	var req OpenShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	shift, err := h.service.OpenShift(req.OpeningFloat, req.Note, requestActor(r))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(shift)
+}
+
+// GetAllShifts lists shifts, newest first; cashier and status narrow the list
+func (h *ShiftHandler) GetAllShifts(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseQueryOptions(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	query := r.URL.Query()
+	shifts, page, err := h.service.GetAllShifts(query.Get("cashier"), query.Get("status"), opts)
+	if err != nil {
+		w.WriteHeader(listErrorStatus(err, http.StatusBadRequest))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeList(w, r, shifts, page)
+}
+
+// GetCurrentShift handles GET /api/shifts/current, the open shift of the signed
+// in cashier
+func (h *ShiftHandler) GetCurrentShift(w http.ResponseWriter, r *http.Request) {
+	shift, err := h.service.GetCurrentShift(requestActor(r))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
+
+func (h *ShiftHandler) GetShiftByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/shifts/"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid shift ID"})
+		return
+	}
+
+	shift, err := h.service.GetShiftByID(uint(id))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
+
+// RecordCashMovement handles POST /api/shifts/{id}/movements
+func (h *ShiftHandler) RecordCashMovement(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/shifts/"), "/movements")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid shift ID"})
+		return
+	}
+
+	var req CashMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	movement, err := h.service.RecordCashMovement(uint(id), req.Type, req.Amount, req.Reason, requestActor(r), requestCan(r, auth.ShiftsManage))
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err.Error() {
+		case "shift not found":
+			status = http.StatusNotFound
+		case "shift belongs to another cashier":
+			status = http.StatusForbidden
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+// CloseShift handles POST /api/shifts/{id}/close and answers with the variance report
+func (h *ShiftHandler) CloseShift(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/shifts/"), "/close")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid shift ID"})
+		return
+	}
+
+	var req CloseShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	report, err := h.service.CloseShift(uint(id), req.CountedCash, req.Note, requestActor(r), requestCan(r, auth.ShiftsManage))
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err.Error() {
+		case "shift not found":
+			status = http.StatusNotFound
+		case "shift belongs to another cashier":
+			status = http.StatusForbidden
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetShiftReport handles GET /api/shifts/{id}/report
+func (h *ShiftHandler) GetShiftReport(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/shifts/"), "/report")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid shift ID"})
+		return
+	}
+
+	report, err := h.service.GetShiftReport(uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "shift not found" {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
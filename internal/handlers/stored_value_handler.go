@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gocats/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type StoredValueHandler struct {
+	service services.StoredValueService
+}
+
+func NewStoredValueHandler(service services.StoredValueService) *StoredValueHandler {
+	return &StoredValueHandler{service: service}
+}
+
+type IssueGiftCardRequest struct {
+	// Code is generated when empty
+	Code      string     `json:"code"`
+	Amount    float64    `json:"amount"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type AdjustStoreCreditRequest struct {
+	// Amount is added to the balance, a negative amount is taken off
+	Amount float64 `json:"amount"`
+	Note   string  `json:"note"`
+}
+
+func (h *StoredValueHandler) IssueGiftCard(w http.ResponseWriter, r *http.Request) {
+	var req IssueGiftCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	giftCard, err := h.service.IssueGiftCard(req.Code, req.Amount, req.ExpiresAt, requestActor(r))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(giftCard)
+}
+
+// GetGiftCard handles GET /api/gift-cards/{code}
+func (h *StoredValueHandler) GetGiftCard(w http.ResponseWriter, r *http.Request) {
+	code := strings.TrimPrefix(r.URL.Path, "/api/gift-cards/")
+	if code == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gift card code is required"})
+		return
+	}
+
+	giftCard, err := h.service.GetGiftCard(code)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(giftCard)
+}
+
+// GetStoreCredit handles GET /api/customers/{id}/store-credit
+func (h *StoredValueHandler) GetStoreCredit(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/customers/"), "/store-credit")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid customer ID"})
+		return
+	}
+
+	account, err := h.service.GetStoreCredit(uint(id))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
+
+// AdjustStoreCredit handles POST /api/customers/{id}/store-credit
+func (h *StoredValueHandler) AdjustStoreCredit(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/customers/"), "/store-credit")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid customer ID"})
+		return
+	}
+
+	var req AdjustStoreCreditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	account, err := h.service.AdjustStoreCredit(uint(id), req.Amount, req.Note, requestActor(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "customer not found" {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"gocats/internal/models"
+	"gocats/internal/repository"
 	"gocats/internal/services"
 	"net/http"
 	"strconv"
@@ -25,7 +26,7 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transaction, err := h.service.Checkout(req)
+	transaction, err := h.service.Checkout(req, requestActor(r))
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -37,6 +38,68 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(transaction)
 }
 
+// PreviewCheckout prices a checkout request with promotions applied, without
+// creating a transaction
+func (h *TransactionHandler) PreviewCheckout(w http.ResponseWriter, r *http.Request) {
+	var req models.CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		return
+	}
+
+	preview, err := h.service.PreviewCheckout(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+// GetAllTransactions lists transactions, newest first. invoice_number matches
+// part of the invoice number; customer_id, start_date and end_date narrow the list.
+func (h *TransactionHandler) GetAllTransactions(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseQueryOptions(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.TransactionFilter{InvoiceNumber: strings.TrimSpace(query.Get("invoice_number"))}
+	if value := query.Get("customer_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid customer ID"})
+			return
+		}
+		customerID := uint(id)
+		filter.CustomerID = &customerID
+	}
+	if filter.CreatedFrom, err = parseDateParam(query, "start_date", false); err == nil {
+		filter.CreatedTo, err = parseDateParam(query, "end_date", true)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	transactions, page, err := h.service.GetAllTransactions(filter, opts)
+	if err != nil {
+		w.WriteHeader(listErrorStatus(err, http.StatusInternalServerError))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeList(w, r, transactions, page)
+}
+
 func (h *TransactionHandler) GetTransactionByID(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/transactions/")
 	id, err := strconv.ParseUint(path, 10, 32)
@@ -57,6 +120,47 @@ func (h *TransactionHandler) GetTransactionByID(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(transaction)
 }
 
+type RefundTransactionRequest struct {
+	Reason string `json:"reason"`
+	// StoreCredit credits the refund to the customer's store credit instead of paying it out
+	StoreCredit bool `json:"store_credit"`
+}
+
+// RefundTransaction handles POST /api/transactions/{id}/refund
+func (h *TransactionHandler) RefundTransaction(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/transactions/"), "/refund")
+	id, err := strconv.ParseUint(path, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid transaction ID"})
+		return
+	}
+
+	// The reason is optional, an empty body is accepted
+	var req RefundTransactionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+			return
+		}
+	}
+
+	transaction, err := h.service.RefundTransaction(uint(id), req.Reason, req.StoreCredit, requestActor(r))
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "transaction not found" {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
 func (h *TransactionHandler) GetTodaySalesSummary(w http.ResponseWriter, r *http.Request) {
 	summary, err := h.service.GetTodaySalesSummary()
 	if err != nil {
@@ -89,3 +193,66 @@ func (h *TransactionHandler) GetSalesSummaryByDateRange(w http.ResponseWriter, r
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(summary)
 }
+
+func (h *TransactionHandler) GetBundleSales(w http.ResponseWriter, r *http.Request) {
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+
+	if startDate == "" || endDate == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "start_date and end_date query parameters are required"})
+		return
+	}
+
+	sales, err := h.service.GetBundleSales(startDate, endDate)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sales)
+}
+
+func (h *TransactionHandler) GetComponentSales(w http.ResponseWriter, r *http.Request) {
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+
+	if startDate == "" || endDate == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "start_date and end_date query parameters are required"})
+		return
+	}
+
+	sales, err := h.service.GetComponentSales(startDate, endDate)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sales)
+}
+
+func (h *TransactionHandler) GetDepartmentSales(w http.ResponseWriter, r *http.Request) {
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+
+	if startDate == "" || endDate == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "start_date and end_date query parameters are required"})
+		return
+	}
+
+	sales, err := h.service.GetDepartmentSales(startDate, endDate)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sales)
+}
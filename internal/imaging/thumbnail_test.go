@@ -0,0 +1,57 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestThumbnailScalesDown(t *testing.T) {
+	data, err := Thumbnail(bytes.NewReader(encodePNG(t, 600, 300)), 300)
+	if err != nil {
+		t.Fatalf("Thumbnail: %v", err)
+	}
+
+	thumb, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	if got := thumb.Bounds().Size(); got != image.Pt(300, 150) {
+		t.Errorf("thumbnail size = %v, want 300x150", got)
+	}
+}
+
+func TestThumbnailRejectsHugeDimensions(t *testing.T) {
+	// A tiny PNG whose header claims 50000x50000 pixels
+	data := encodePNG(t, 1, 1)
+	ihdr := data[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:4], 50000)
+	binary.BigEndian.PutUint32(ihdr[4:8], 50000)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+
+	_, err := Thumbnail(bytes.NewReader(data), 300)
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("Thumbnail error = %v, want too large", err)
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"database/sql/driver"
+)
+
+// Attribute value types supported by category attribute schemas
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+)
+
+// AttributeDefinition describes one custom product attribute allowed in a category
+type AttributeDefinition struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required,omitempty"`
+	Allowed  []string `json:"allowed,omitempty"`
+}
+
+// AttributeSchema is the list of attribute definitions of a category, stored as JSONB
+type AttributeSchema []AttributeDefinition
+
+func (s AttributeSchema) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	return jsonbValue(s)
+}
+
+func (s *AttributeSchema) Scan(value interface{}) error {
+	return jsonbScan(value, s)
+}
+
+// Find returns the definition of the named attribute
+func (s AttributeSchema) Find(name string) (AttributeDefinition, bool) {
+	for _, def := range s {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return AttributeDefinition{}, false
+}
@@ -0,0 +1,56 @@
+package models
+
+// BundleItem is a component of a bundle product with the quantity consumed per bundle sold
+type BundleItem struct {
+	ID          uint `gorm:"primaryKey" json:"id"`
+	BundleID    uint `gorm:"not null;uniqueIndex:idx_bundle_component" json:"bundle_id"`
+	ComponentID uint `gorm:"not null;uniqueIndex:idx_bundle_component;index" json:"component_id"`
+	Quantity    int  `gorm:"not null" json:"quantity"`
+
+	Component Product `gorm:"foreignKey:ComponentID" json:"component,omitempty"`
+}
+
+func (BundleItem) TableName() string {
+	return "bundle_items"
+}
+
+// TransactionBundleComponent records the component stock consumed by a bundle line
+type TransactionBundleComponent struct {
+	ID                  uint `gorm:"primaryKey" json:"id"`
+	TransactionDetailID uint `gorm:"not null;index" json:"transaction_detail_id"`
+	ProductID           uint `gorm:"not null;index" json:"product_id"`
+	Quantity            int  `gorm:"not null" json:"quantity"`
+}
+
+func (TransactionBundleComponent) TableName() string {
+	return "transaction_bundle_components"
+}
+
+type BundleComponentInput struct {
+	ProductID uint `json:"product_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+type BundleComponentResponse struct {
+	ProductID uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	Stock     int    `json:"stock"`
+}
+
+// BundleSales represents units and revenue of a bundle product in a report
+type BundleSales struct {
+	ProductID uint    `json:"product_id"`
+	Name      string  `json:"name"`
+	QtySold   int     `json:"qty_sold"`
+	Revenue   float64 `json:"revenue"`
+}
+
+// ComponentSales represents units of a product sold directly and through bundles
+type ComponentSales struct {
+	ProductID uint   `json:"product_id"`
+	Name      string `json:"name"`
+	DirectQty int    `json:"direct_qty"`
+	BundleQty int    `json:"bundle_qty"`
+	TotalQty  int    `json:"total_qty"`
+}
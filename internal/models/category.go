@@ -1,5 +1,11 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 /*
 type Category struct {
 	ID          int    `json:"id"`
@@ -13,10 +19,54 @@ type Category struct {
 	ID          uint   `gorm:"primaryKey" json:"id"`
 	Name        string `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
 	Description string `gorm:"type:text" json:"description"`
+	ParentID    *uint  `gorm:"index" json:"parent_id"`
+
+	AttributeSchema AttributeSchema `gorm:"type:jsonb" json:"attribute_schema,omitempty"`
+
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 
-	Products []Product `gorm:"foreignKey:CategoryID" json:"products,omitempty"`
+	Products []Product  `gorm:"foreignKey:CategoryID" json:"products,omitempty"`
+	Children []Category `gorm:"foreignKey:ParentID" json:"children,omitempty"`
 }
 
 func (Category) TableName() string {
 	return "categories"
 }
+
+// CategoryRef is a lightweight category reference used in breadcrumbs
+type CategoryRef struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// CategoryNode is a category with its nested subcategories
+type CategoryNode struct {
+	ID          uint           `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	ParentID    *uint          `json:"parent_id"`
+	Children    []CategoryNode `json:"children"`
+}
+
+// DepartmentSales represents sales rolled up to a top-level category
+type DepartmentSales struct {
+	CategoryID uint    `json:"category_id"`
+	Name       string  `json:"name"`
+	QtySold    int     `json:"qty_sold"`
+	Revenue    float64 `json:"revenue"`
+}
+
+// CategoryMerge records that source categories were merged into a target category
+type CategoryMerge struct {
+	ID            uint        `gorm:"primaryKey" json:"id"`
+	TargetID      uint        `gorm:"not null;index" json:"target_id"`
+	SourceIDs     UintArray   `gorm:"type:jsonb;not null" json:"source_ids"`
+	SourceNames   StringArray `gorm:"type:jsonb;not null" json:"source_names"`
+	ProductsMoved int64       `gorm:"not null" json:"products_moved"`
+	SourcesPurged bool        `gorm:"not null;default:false" json:"sources_purged"`
+	CreatedAt     time.Time   `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (CategoryMerge) TableName() string {
+	return "category_merges"
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Customer struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Name            string         `gorm:"size:200;not null" json:"name"`
+	Phone           string         `gorm:"size:30;index" json:"phone,omitempty"`
+	Email           string         `gorm:"size:255;index" json:"email,omitempty"`
+	Notes           string         `gorm:"type:text" json:"notes,omitempty"`
+	CustomerGroupID *uint          `gorm:"index" json:"customer_group_id,omitempty"`
+	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// CustomerGroup gives the customer the price tiers of the group
+	CustomerGroup *CustomerGroup `gorm:"foreignKey:CustomerGroupID" json:"customer_group,omitempty"`
+}
+
+func (Customer) TableName() string {
+	return "customers"
+}
+
+// CustomerStats summarizes the purchases of a customer
+type CustomerStats struct {
+	TransactionCount int64      `json:"transaction_count"`
+	LifetimeValue    float64    `json:"lifetime_value"`
+	LastPurchaseAt   *time.Time `json:"last_purchase_at,omitempty"`
+}
+
+// CustomerPurchaseHistory is a customer with purchase totals and a page of
+// their transactions, newest first
+type CustomerPurchaseHistory struct {
+	Customer     Customer      `json:"customer"`
+	Stats        CustomerStats `json:"stats"`
+	Transactions []Transaction `json:"transactions"`
+}
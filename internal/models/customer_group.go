@@ -0,0 +1,55 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// CustomerGroup gives its customers, e.g. resellers, their own price tiers
+type CustomerGroup struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:100;not null;uniqueIndex" json:"name"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Tiers []PriceTier `gorm:"foreignKey:CustomerGroupID;constraint:OnDelete:CASCADE" json:"tiers"`
+}
+
+func (CustomerGroup) TableName() string {
+	return "customer_groups"
+}
+
+// PriceTier sets the price of a product for a customer group from a minimum
+// quantity, either a fixed price or a discount off the regular price
+type PriceTier struct {
+	ID              uint     `gorm:"primaryKey" json:"id"`
+	CustomerGroupID uint     `gorm:"not null;index" json:"customer_group_id"`
+	ProductID       uint     `gorm:"not null;index" json:"product_id"`
+	MinQuantity     int      `gorm:"not null;default:1" json:"min_quantity"`
+	Price           *float64 `gorm:"type:decimal(10,2)" json:"price,omitempty"`
+	DiscountPercent *float64 `gorm:"type:decimal(5,2)" json:"discount_percent,omitempty"`
+}
+
+func (PriceTier) TableName() string {
+	return "price_tiers"
+}
+
+// Apply returns the tier price for a line with the given regular price. Like
+// price list entries, a fixed price does not replace the own price of a variant.
+func (t PriceTier) Apply(regular float64, ownPrice bool) float64 {
+	if t.DiscountPercent != nil {
+		return math.Round(regular*(100-*t.DiscountPercent)) / 100
+	}
+	if t.Price != nil && !ownPrice {
+		return *t.Price
+	}
+	return regular
+}
+
+// TierPrice is the unit price a customer group pays from MinQuantity units
+type TierPrice struct {
+	TierID      uint    `json:"tier_id"`
+	MinQuantity int     `json:"min_quantity"`
+	Price       float64 `json:"price"`
+}
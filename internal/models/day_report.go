@@ -0,0 +1,88 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// Day report types
+const (
+	// ReportX is the business day so far, computed on demand and not stored
+	ReportX = "x"
+	// ReportZ closes the business day; it is numbered, stored and never changed
+	ReportZ = "z"
+)
+
+// PaymentLoyaltyPoints names the part of totals paid with loyalty points in
+// payment method breakdowns
+const PaymentLoyaltyPoints = "loyalty_points"
+
+// DayReport summarizes the sales of a business day. A business day runs from
+// the end of the last Z-report and covers the transactions and refunds with
+// IDs above LastTransactionID and LastRefundID of that report.
+//
+// Gross sales are line prices before discounts. Refunds return transactions
+// sold on an earlier business day and voids those sold on the same one; both
+// count the full transaction total. Net sales are gross sales less discounts,
+// refunds and voids, and taxes are the tax included in net sales.
+type DayReport struct {
+	ID                uint      `gorm:"primaryKey" json:"id,omitempty"`
+	Type              string    `gorm:"size:1;not null;default:z" json:"type"`
+	Number            int64     `gorm:"not null;uniqueIndex" json:"number,omitempty"`
+	PeriodStart       time.Time `gorm:"not null" json:"period_start"`
+	PeriodEnd         time.Time `gorm:"not null" json:"period_end"`
+	LastTransactionID uint      `gorm:"not null;default:0" json:"last_transaction_id"`
+	LastRefundID      uint      `gorm:"not null;default:0" json:"last_refund_id"`
+
+	TransactionCount int64   `gorm:"not null;default:0" json:"transaction_count"`
+	GrossSales       float64 `gorm:"type:decimal(12,2);not null;default:0" json:"gross_sales"`
+	Discounts        float64 `gorm:"type:decimal(12,2);not null;default:0" json:"discounts"`
+	RefundCount      int64   `gorm:"not null;default:0" json:"refund_count"`
+	Refunds          float64 `gorm:"type:decimal(12,2);not null;default:0" json:"refunds"`
+	VoidCount        int64   `gorm:"not null;default:0" json:"void_count"`
+	Voids            float64 `gorm:"type:decimal(12,2);not null;default:0" json:"voids"`
+	NetSales         float64 `gorm:"type:decimal(12,2);not null;default:0" json:"net_sales"`
+	Taxes            float64 `gorm:"type:decimal(12,2);not null;default:0" json:"taxes"`
+	// CashRefunds is the cash paid out of the drawers for refunds and voids
+	CashRefunds float64 `gorm:"type:decimal(12,2);not null;default:0" json:"cash_refunds"`
+
+	Payments   PaymentMethodTotals `gorm:"type:jsonb" json:"payments"`
+	Categories CategoryTotals      `gorm:"type:jsonb" json:"categories"`
+
+	CreatedBy string    `gorm:"size:100" json:"created_by,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (DayReport) TableName() string {
+	return "day_reports"
+}
+
+// PaymentMethodTotals is a []PaymentMethodTotal stored as a JSONB column
+type PaymentMethodTotals []PaymentMethodTotal
+
+func (t PaymentMethodTotals) Value() (driver.Value, error) {
+	return jsonbValue(t)
+}
+
+func (t *PaymentMethodTotals) Scan(value interface{}) error {
+	return jsonbScan(value, t)
+}
+
+// CategoryTotal is the quantity and net amount sold of the products of a category
+type CategoryTotal struct {
+	CategoryID uint    `json:"category_id"`
+	Name       string  `json:"name"`
+	Quantity   int64   `json:"quantity"`
+	Amount     float64 `json:"amount"`
+}
+
+// CategoryTotals is a []CategoryTotal stored as a JSONB column
+type CategoryTotals []CategoryTotal
+
+func (t CategoryTotals) Value() (driver.Value, error) {
+	return jsonbValue(t)
+}
+
+func (t *CategoryTotals) Scan(value interface{}) error {
+	return jsonbScan(value, t)
+}
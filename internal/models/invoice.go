@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// InvoiceSequence is the last invoice number allocated in a series, the part
+// of the invoice number before the sequence (e.g. "INV-S01-20261019")
+type InvoiceSequence struct {
+	Series    string    `gorm:"primaryKey;size:100" json:"series"`
+	Value     int64     `gorm:"not null" json:"value"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (InvoiceSequence) TableName() string {
+	return "invoice_sequences"
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// StringMap is a map[string]string stored as a JSONB column
+type StringMap map[string]string
+
+func (m StringMap) Value() (driver.Value, error) {
+	return jsonbValue(m)
+}
+
+func (m *StringMap) Scan(value interface{}) error {
+	return jsonbScan(value, m)
+}
+
+// StringArray is a []string stored as a JSONB column
+type StringArray []string
+
+func (a StringArray) Value() (driver.Value, error) {
+	return jsonbValue(a)
+}
+
+func (a *StringArray) Scan(value interface{}) error {
+	return jsonbScan(value, a)
+}
+
+// UintArray is a []uint stored as a JSONB column
+type UintArray []uint
+
+func (a UintArray) Value() (driver.Value, error) {
+	return jsonbValue(a)
+}
+
+func (a *UintArray) Scan(value interface{}) error {
+	return jsonbScan(value, a)
+}
+
+// JSONMap is a free-form JSON object stored as a JSONB column
+type JSONMap map[string]interface{}
+
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	return jsonbValue(m)
+}
+
+func (m *JSONMap) Scan(value interface{}) error {
+	return jsonbScan(value, m)
+}
+
+func jsonbValue(v interface{}) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func jsonbScan(value interface{}, dest interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return fmt.Errorf("unsupported JSONB value type %T", value)
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Loyalty ledger entry types
+const (
+	LoyaltyEarn     = "earn"
+	LoyaltyRedeem   = "redeem"
+	LoyaltyReversal = "reversal"
+)
+
+// LoyaltyEntry is a line of the points ledger of a customer. Earned points
+// are positive, redeemed points negative; the balance is the sum of all entries.
+type LoyaltyEntry struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	CustomerID    uint      `gorm:"not null;index" json:"customer_id"`
+	TransactionID *uint     `gorm:"index" json:"transaction_id,omitempty"`
+	Type          string    `gorm:"size:20;not null" json:"type"`
+	Points        int       `gorm:"not null" json:"points"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (LoyaltyEntry) TableName() string {
+	return "loyalty_entries"
+}
+
+// LoyaltyBalance is the points balance of a customer with its ledger, newest first
+type LoyaltyBalance struct {
+	CustomerID uint           `json:"customer_id"`
+	Points     int            `json:"points"`
+	Value      float64        `json:"value"`
+	Entries    []LoyaltyEntry `json:"entries"`
+}
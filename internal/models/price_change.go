@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PriceChange records a change of the regular price of a product or of the
+// price override of a variant
+type PriceChange struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"not null;index" json:"product_id"`
+	VariantID *uint     `gorm:"index" json:"variant_id,omitempty"`
+	OldPrice  float64   `gorm:"type:decimal(10,2);not null" json:"old_price"`
+	NewPrice  float64   `gorm:"type:decimal(10,2);not null" json:"new_price"`
+	ChangedBy string    `gorm:"size:100;not null" json:"changed_by"`
+	ChangedAt time.Time `gorm:"not null;index" json:"changed_at"`
+
+	// Filled by report queries
+	ProductName string `gorm:"->;-:migration" json:"product_name,omitempty"`
+	SKU         string `gorm:"->;-:migration" json:"sku,omitempty"`
+}
+
+func (PriceChange) TableName() string {
+	return "price_changes"
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// PriceList is a set of temporary prices, e.g. a weekend promotion, active
+// from StartsAt until EndsAt
+type PriceList struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	StartsAt  time.Time `gorm:"not null;index" json:"starts_at"`
+	EndsAt    time.Time `gorm:"not null;index" json:"ends_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Entries []PriceListEntry `gorm:"foreignKey:PriceListID;constraint:OnDelete:CASCADE" json:"entries"`
+}
+
+func (PriceList) TableName() string {
+	return "price_lists"
+}
+
+// ActiveAt reports whether the price list applies at t
+func (l PriceList) ActiveAt(t time.Time) bool {
+	return !t.Before(l.StartsAt) && t.Before(l.EndsAt)
+}
+
+// PriceListEntry sets the price of a product, or a discount on a product or on
+// every product of a category and its subcategories
+type PriceListEntry struct {
+	ID              uint     `gorm:"primaryKey" json:"id"`
+	PriceListID     uint     `gorm:"not null;index" json:"price_list_id"`
+	ProductID       *uint    `gorm:"index" json:"product_id,omitempty"`
+	CategoryID      *uint    `gorm:"index" json:"category_id,omitempty"`
+	Price           *float64 `gorm:"type:decimal(10,2)" json:"price,omitempty"`
+	DiscountPercent *float64 `gorm:"type:decimal(5,2)" json:"discount_percent,omitempty"`
+
+	PriceList *PriceList `gorm:"foreignKey:PriceListID" json:"-"`
+}
+
+func (PriceListEntry) TableName() string {
+	return "price_list_entries"
+}
+
+// Apply returns the entry price for a line with the given regular price. A
+// fixed price only replaces the product price, variants with their own price
+// keep it unless a discount applies.
+func (e PriceListEntry) Apply(regular float64, ownPrice bool) float64 {
+	if e.DiscountPercent != nil {
+		return math.Round(regular*(100-*e.DiscountPercent)) / 100
+	}
+	if e.Price != nil && !ownPrice {
+		return *e.Price
+	}
+	return regular
+}
+
+// PriceListRef identifies the price list behind a current price
+type PriceListRef struct {
+	ID     uint      `json:"id"`
+	Name   string    `json:"name"`
+	EndsAt time.Time `json:"ends_at"`
+}
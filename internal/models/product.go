@@ -1,5 +1,11 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 /*
 type Product struct {
 	ID    int    `json:"id"`
@@ -10,23 +16,89 @@ type Product struct {
 */
 
 type Product struct {
-	ID         uint     `gorm:"primaryKey" json:"id"`
-	Name       string   `gorm:"size:200;not null" json:"name"`
-	Price      float64  `gorm:"type:decimal(10,2);not null" json:"price"`
-	Stock      int      `gorm:"default:0" json:"stock"`
-	CategoryID uint     `gorm:"not null;index" json:"category_id"`
-	Category   Category `gorm:"foreignKey:CategoryID" json:"category"`
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	Name        string   `gorm:"size:200;not null" json:"name"`
+	Description string   `gorm:"type:text" json:"description"`
+	Attributes  JSONMap  `gorm:"type:jsonb" json:"attributes,omitempty"`
+	Price       float64  `gorm:"type:decimal(10,2);not null" json:"price"`
+	Stock       int      `gorm:"default:0" json:"stock"`
+	CategoryID  uint     `gorm:"not null;index" json:"category_id"`
+	IsBundle    bool     `gorm:"default:false" json:"is_bundle"`
+	Category    Category `gorm:"foreignKey:CategoryID" json:"category"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	BundleItems []BundleItem     `gorm:"foreignKey:BundleID;constraint:OnDelete:CASCADE" json:"bundle_items,omitempty"`
+	Options     []ProductOption  `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE" json:"options,omitempty"`
+	Variants    []ProductVariant `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE" json:"variants,omitempty"`
+	Images      []ProductImage   `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE" json:"images,omitempty"`
 }
 
 func (Product) TableName() string {
 	return "products"
 }
 
+// BundleAvailability returns how many bundles can be assembled from the
+// current component stock. BundleItems.Component must be preloaded.
+func (p Product) BundleAvailability() int {
+	available := -1
+	for _, item := range p.BundleItems {
+		if item.Quantity <= 0 {
+			continue
+		}
+		n := item.Component.Stock / item.Quantity
+		if available < 0 || n < available {
+			available = n
+		}
+	}
+	if available < 0 {
+		return 0
+	}
+	return available
+}
+
+// AvailableStock returns the sellable stock: component availability for
+// bundles, the sum of variant stock for parent products, otherwise Stock
+func (p Product) AvailableStock() int {
+	if p.IsBundle {
+		return p.BundleAvailability()
+	}
+	if p.HasVariants() {
+		total := 0
+		for _, variant := range p.Variants {
+			total += variant.Stock
+		}
+		return total
+	}
+	return p.Stock
+}
+
+// HasVariants reports whether the product is sold through its variants
+func (p Product) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
 type ProductResponse struct {
-	ID         uint      `json:"id"`
-	Name       string    `json:"name"`
-	Price      float64   `json:"price"`
-	Stock      int       `json:"stock"`
-	CategoryID uint      `json:"category_id"`
-	Category   *Category `json:"category,omitempty"`
+	ID           uint                      `json:"id"`
+	Name         string                    `json:"name"`
+	Description  string                    `json:"description"`
+	Attributes   JSONMap                   `json:"attributes,omitempty"`
+	Price        float64                   `json:"price"`
+	CurrentPrice float64                   `json:"current_price"`
+	PriceList    *PriceListRef             `json:"price_list,omitempty"`
+	PriceTiers   []TierPrice               `json:"price_tiers,omitempty"`
+	Stock        int                       `json:"stock"`
+	CategoryID   uint                      `json:"category_id"`
+	Category     *Category                 `json:"category,omitempty"`
+	Breadcrumbs  []CategoryRef             `json:"breadcrumbs,omitempty"`
+	IsBundle     bool                      `json:"is_bundle"`
+	Components   []BundleComponentResponse `json:"components,omitempty"`
+	Options      []ProductOption           `json:"options,omitempty"`
+	Variants     []VariantResponse         `json:"variants,omitempty"`
+	Images       []ImageResponse           `json:"images,omitempty"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+	ArchivedAt   *time.Time                `json:"archived_at,omitempty"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ProductImage is a photo of a product kept in the configured storage backend
+type ProductImage struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ProductID    uint      `gorm:"not null;index" json:"product_id"`
+	Key          string    `gorm:"size:255;not null" json:"-"`
+	ThumbnailKey string    `gorm:"size:255;not null" json:"-"`
+	URL          string    `gorm:"size:500;not null" json:"url"`
+	ThumbnailURL string    `gorm:"size:500;not null" json:"thumbnail_url"`
+	ContentType  string    `gorm:"size:100" json:"content_type"`
+	Position     int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (ProductImage) TableName() string {
+	return "product_images"
+}
+
+type ImageResponse struct {
+	ID           uint   `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Position     int    `json:"position"`
+}
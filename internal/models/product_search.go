@@ -0,0 +1,15 @@
+package models
+
+// ProductSearchResult is a product returned by the search endpoint, most relevant first
+type ProductSearchResult struct {
+	Product    ProductResponse  `json:"product"`
+	Rank       float64          `json:"rank"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// SearchHighlights holds the HTML-escaped text with the matches wrapped in <mark> tags
+type SearchHighlights struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	SKU         string `json:"sku,omitempty"`
+}
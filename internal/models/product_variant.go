@@ -0,0 +1,59 @@
+package models
+
+// ProductOption is an option type of a parent product, e.g. "size" with values S, M, L
+type ProductOption struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	ProductID uint        `gorm:"not null;uniqueIndex:idx_product_option_name" json:"product_id"`
+	Name      string      `gorm:"size:50;not null;uniqueIndex:idx_product_option_name" json:"name"`
+	Values    StringArray `gorm:"type:jsonb;not null" json:"values"`
+}
+
+func (ProductOption) TableName() string {
+	return "product_options"
+}
+
+// HasValue reports whether value is one of the allowed option values
+func (o ProductOption) HasValue(value string) bool {
+	for _, v := range o.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ProductVariant is a sellable combination of option values of a parent product
+// with its own SKU, stock and optional price override
+type ProductVariant struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"not null;index" json:"product_id"`
+	SKU       string    `gorm:"size:64;not null;uniqueIndex" json:"sku"`
+	Options   StringMap `gorm:"type:jsonb;not null" json:"options"`
+	Price     *float64  `gorm:"type:decimal(10,2)" json:"price,omitempty"`
+	Stock     int       `gorm:"default:0" json:"stock"`
+
+	Product *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (ProductVariant) TableName() string {
+	return "product_variants"
+}
+
+// EffectivePrice returns the variant price override, falling back to the parent price
+func (v ProductVariant) EffectivePrice(parentPrice float64) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return parentPrice
+}
+
+type VariantResponse struct {
+	ID           uint          `json:"id"`
+	SKU          string        `json:"sku"`
+	Options      StringMap     `json:"options"`
+	Price        float64       `json:"price"`
+	CurrentPrice float64       `json:"current_price"`
+	PriceList    *PriceListRef `json:"price_list,omitempty"`
+	PriceTiers   []TierPrice   `json:"price_tiers,omitempty"`
+	Stock        int           `json:"stock"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Promotion types
+const (
+	// PromotionBuyXGetY gives GetQuantity units free for every BuyQuantity units
+	// bought, the cheapest units are free ("buy 2 get 1")
+	PromotionBuyXGetY = "buy_x_get_y"
+	// PromotionMultiBuy sells DealQuantity units for DealPrice ("3 for 10k")
+	PromotionMultiBuy = "multi_buy"
+	// PromotionTiered discounts all units by the percentage of the highest
+	// tier reached by the quantity bought
+	PromotionTiered = "tiered"
+)
+
+// Promotion is a rule evaluated at checkout against the lines of products it
+// is scoped to. Without product and category IDs it applies to every product.
+// Deleted promotions are kept so transaction lines stay attributed.
+type Promotion struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Name        string     `gorm:"size:100;not null" json:"name"`
+	Type        string     `gorm:"size:20;not null" json:"type"`
+	Priority    int        `gorm:"default:0" json:"priority"`
+	StartsAt    *time.Time `gorm:"index" json:"starts_at,omitempty"`
+	EndsAt      *time.Time `gorm:"index" json:"ends_at,omitempty"`
+	ProductIDs  UintArray  `gorm:"type:jsonb" json:"product_ids,omitempty"`
+	CategoryIDs UintArray  `gorm:"type:jsonb" json:"category_ids,omitempty"`
+
+	BuyQuantity  int            `json:"buy_quantity,omitempty"`
+	GetQuantity  int            `json:"get_quantity,omitempty"`
+	DealQuantity int            `json:"deal_quantity,omitempty"`
+	DealPrice    float64        `gorm:"type:decimal(10,2)" json:"deal_price,omitempty"`
+	Tiers        PromotionTiers `gorm:"type:jsonb" json:"tiers,omitempty"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Promotion) TableName() string {
+	return "promotions"
+}
+
+// PromotionTier is a quantity threshold of a tiered promotion
+type PromotionTier struct {
+	MinQuantity     int     `json:"min_quantity"`
+	DiscountPercent float64 `json:"discount_percent"`
+}
+
+// PromotionTiers is a []PromotionTier stored as a JSONB column
+type PromotionTiers []PromotionTier
+
+func (t PromotionTiers) Value() (driver.Value, error) {
+	return jsonbValue(t)
+}
+
+func (t *PromotionTiers) Scan(value interface{}) error {
+	return jsonbScan(value, t)
+}
+
+// CheckoutPreview is the dry-run pricing of a checkout request
+type CheckoutPreview struct {
+	Lines       []PreviewLine `json:"lines"`
+	Subtotal    float64       `json:"subtotal"`
+	Discount    float64       `json:"discount"`
+	TotalAmount float64       `json:"total_amount"`
+	TaxAmount   float64       `json:"tax_amount"`
+}
+
+// PreviewLine is a priced checkout line with the promotion applied to it
+type PreviewLine struct {
+	ProductID     uint    `json:"product_id"`
+	VariantID     *uint   `json:"variant_id,omitempty"`
+	Quantity      int     `json:"quantity"`
+	UnitPrice     float64 `json:"unit_price"`
+	Discount      float64 `json:"discount"`
+	Subtotal      float64 `json:"subtotal"`
+	PriceListID   *uint   `json:"price_list_id,omitempty"`
+	PriceTierID   *uint   `json:"price_tier_id,omitempty"`
+	PromotionID   *uint   `json:"promotion_id,omitempty"`
+	PromotionName string  `json:"promotion_name,omitempty"`
+}
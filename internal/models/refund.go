@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Refund records that a transaction was refunded in full and its stock
+// returned. StoreCredit is the part of the amount credited to the customer's
+// store credit instead of being paid out. CashAmount is the part paid out of
+// the cash drawer of the shift ShiftID.
+type Refund struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	TransactionID uint      `gorm:"not null;uniqueIndex" json:"transaction_id"`
+	Amount        float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
+	StoreCredit   float64   `gorm:"type:decimal(10,2);default:0" json:"store_credit,omitempty"`
+	CashAmount    float64   `gorm:"type:decimal(10,2);default:0" json:"cash_amount,omitempty"`
+	ShiftID       *uint     `gorm:"index" json:"shift_id,omitempty"`
+	Reason        string    `gorm:"type:text" json:"reason,omitempty"`
+	RefundedBy    string    `gorm:"size:100;not null" json:"refunded_by"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (Refund) TableName() string {
+	return "refunds"
+}
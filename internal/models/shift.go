@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// Shift statuses
+const (
+	ShiftOpen   = "open"
+	ShiftClosed = "closed"
+)
+
+// Cash movement types
+const (
+	CashPayIn  = "pay_in"
+	CashPayOut = "pay_out"
+)
+
+// Shift is the session of a cashier at the till, from opening with a float of
+// cash to closing with the cash counted in the drawer. A cashier has at most
+// one open shift.
+type Shift struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Cashier      string     `gorm:"size:100;not null;index;uniqueIndex:idx_shifts_open_cashier,where:status = 'open'" json:"cashier"`
+	Status       string     `gorm:"size:20;not null;default:open;index" json:"status"`
+	OpeningFloat float64    `gorm:"type:decimal(10,2);not null;default:0" json:"opening_float"`
+	OpenedAt     time.Time  `gorm:"not null" json:"opened_at"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+	ClosedBy     string     `gorm:"size:100" json:"closed_by,omitempty"`
+	// ExpectedCash is what the drawer should hold at closing, CountedCash what
+	// was counted and Variance the difference, negative when cash is missing
+	ExpectedCash *float64 `gorm:"type:decimal(10,2)" json:"expected_cash,omitempty"`
+	CountedCash  *float64 `gorm:"type:decimal(10,2)" json:"counted_cash,omitempty"`
+	Variance     *float64 `gorm:"type:decimal(10,2)" json:"variance,omitempty"`
+	Note         string   `gorm:"type:text" json:"note,omitempty"`
+
+	Movements []CashMovement `gorm:"foreignKey:ShiftID;constraint:OnDelete:CASCADE" json:"movements,omitempty"`
+}
+
+func (Shift) TableName() string {
+	return "shifts"
+}
+
+// CashMovement is cash put into (pay-in) or taken out of (pay-out) the drawer
+// during a shift other than for a sale or refund, e.g. change from the bank or
+// paying a supplier
+type CashMovement struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ShiftID   uint      `gorm:"not null;index" json:"shift_id"`
+	Type      string    `gorm:"size:20;not null" json:"type"`
+	Amount    float64   `gorm:"type:decimal(10,2);not null;check:amount > 0" json:"amount"`
+	Reason    string    `gorm:"type:text" json:"reason,omitempty"`
+	CreatedBy string    `gorm:"size:100;not null" json:"created_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (CashMovement) TableName() string {
+	return "cash_movements"
+}
+
+// PaymentMethodTotal is the number of transactions paid with a payment method
+// and the amount they paid with it
+type PaymentMethodTotal struct {
+	Method string  `json:"method"`
+	Count  int64   `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+// ShiftReport reconciles the cash drawer of a shift: the opening float plus
+// cash sales and pay-ins, less cash refunds and pay-outs, is the cash expected
+// in the drawer. The variance is counted minus expected cash.
+type ShiftReport struct {
+	Shift            Shift                `json:"shift"`
+	TransactionCount int64                `json:"transaction_count"`
+	SalesTotal       float64              `json:"sales_total"`
+	Payments         []PaymentMethodTotal `json:"payments"`
+	OpeningFloat     float64              `json:"opening_float"`
+	CashSales        float64              `json:"cash_sales"`
+	CashRefunds      float64              `json:"cash_refunds"`
+	PayIns           float64              `json:"pay_ins"`
+	PayOuts          float64              `json:"pay_outs"`
+	ExpectedCash     float64              `json:"expected_cash"`
+	CountedCash      *float64             `json:"counted_cash,omitempty"`
+	Variance         *float64             `json:"variance,omitempty"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// Stored value account types
+const (
+	AccountGiftCard    = "gift_card"
+	AccountStoreCredit = "store_credit"
+)
+
+// Stored value ledger entry types
+const (
+	ValueIssue      = "issue"
+	ValueRedeem     = "redeem"
+	ValueRefund     = "refund"
+	ValueAdjustment = "adjustment"
+)
+
+// StoredValueAccount holds a balance that can pay for checkouts: a gift card
+// identified by its code, or the store credit of a customer. The balance is
+// kept on the row and every change is written to the ledger in the same DB
+// transaction.
+type StoredValueAccount struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Type       string     `gorm:"size:20;not null;index" json:"type"`
+	Code       *string    `gorm:"size:32;uniqueIndex" json:"code,omitempty"`
+	CustomerID *uint      `gorm:"uniqueIndex" json:"customer_id,omitempty"`
+	Balance    float64    `gorm:"type:decimal(10,2);not null;default:0;check:balance >= 0" json:"balance"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Entries []StoredValueEntry `gorm:"foreignKey:AccountID" json:"entries,omitempty"`
+}
+
+func (StoredValueAccount) TableName() string {
+	return "stored_value_accounts"
+}
+
+// ExpiredAt reports whether a gift card can no longer be used at t
+func (a StoredValueAccount) ExpiredAt(t time.Time) bool {
+	return a.ExpiresAt != nil && !t.Before(*a.ExpiresAt)
+}
+
+// StoredValueEntry is a line of the ledger of an account. Amounts are positive
+// when value is added and negative when it is spent.
+type StoredValueEntry struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	AccountID     uint      `gorm:"not null;index" json:"account_id"`
+	TransactionID *uint     `gorm:"index" json:"transaction_id,omitempty"`
+	Type          string    `gorm:"size:20;not null" json:"type"`
+	Amount        float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
+	BalanceAfter  float64   `gorm:"type:decimal(10,2);not null" json:"balance_after"`
+	Note          string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedBy     string    `gorm:"size:100;not null" json:"created_by"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (StoredValueEntry) TableName() string {
+	return "stored_value_entries"
+}
+
+// Tender pays part of a checkout from a gift card or the store credit of the
+// checkout customer
+type Tender struct {
+	Type   string  `json:"type"`
+	Code   string  `json:"code,omitempty"`
+	Amount float64 `json:"amount"`
+}
+
+// TransactionTender is the amount a transaction took from a stored value account
+type TransactionTender struct {
+	ID            uint    `gorm:"primaryKey" json:"id"`
+	TransactionID uint    `gorm:"not null;index" json:"transaction_id"`
+	AccountID     uint    `gorm:"not null;index" json:"account_id"`
+	Type          string  `gorm:"size:20;not null" json:"type"`
+	Amount        float64 `gorm:"type:decimal(10,2);not null" json:"amount"`
+
+	Account *StoredValueAccount `gorm:"foreignKey:AccountID" json:"-"`
+}
+
+func (TransactionTender) TableName() string {
+	return "transaction_tenders"
+}
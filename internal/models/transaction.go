@@ -2,10 +2,45 @@ package models
 
 import "time"
 
+// Payment methods of the balance due of a transaction
+const (
+	PaymentCash     = "cash"
+	PaymentCard     = "card"
+	PaymentTransfer = "transfer"
+	PaymentOther    = "other"
+)
+
+// PaymentMethods lists the accepted payment methods
+var PaymentMethods = []string{PaymentCash, PaymentCard, PaymentTransfer, PaymentOther}
+
 type Transaction struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	TotalAmount float64   `gorm:"type:decimal(10,2);not null" json:"total_amount"`
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID uint `gorm:"primaryKey" json:"id"`
+	// InvoiceNumber is the sequential number printed on the receipt, allocated
+	// at checkout. Transactions from before invoice numbering have none.
+	InvoiceNumber *string `gorm:"size:100;uniqueIndex" json:"invoice_number,omitempty"`
+	TotalAmount   float64 `gorm:"type:decimal(10,2);not null" json:"total_amount"`
+	CustomerID    *uint   `gorm:"index" json:"customer_id,omitempty"`
+	// ShiftID is the open shift of the cashier who rang up the transaction
+	ShiftID *uint `gorm:"index" json:"shift_id,omitempty"`
+	// PointsRedeemed loyalty points paid PointsAmount of the total
+	PointsRedeemed int     `gorm:"default:0" json:"points_redeemed,omitempty"`
+	PointsAmount   float64 `gorm:"type:decimal(10,2);default:0" json:"points_amount,omitempty"`
+	PointsEarned   int     `gorm:"default:0" json:"points_earned,omitempty"`
+	// TenderedAmount is the part of the amount due paid with gift cards and store credit
+	TenderedAmount float64 `gorm:"type:decimal(10,2);default:0" json:"tendered_amount,omitempty"`
+	// TaxAmount is the tax included in the total
+	TaxAmount float64 `gorm:"type:decimal(10,2);default:0" json:"tax_amount"`
+	// PaymentMethod paid the balance due; AmountPaid is what was handed over
+	// and Change what was given back
+	PaymentMethod string    `gorm:"size:20;not null;default:cash" json:"payment_method"`
+	AmountPaid    float64   `gorm:"type:decimal(10,2);default:0" json:"amount_paid"`
+	Change        float64   `gorm:"type:decimal(10,2);default:0" json:"change"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Customer *Customer `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	Refund   *Refund   `gorm:"foreignKey:TransactionID" json:"refund,omitempty"`
+
+	Tenders []TransactionTender `gorm:"foreignKey:TransactionID;constraint:OnDelete:CASCADE" json:"tenders,omitempty"`
 
 	TransactionDetails []TransactionDetail `gorm:"foreignKey:TransactionID;constraint:OnDelete:CASCADE" json:"transaction_details,omitempty"`
 }
@@ -14,15 +49,37 @@ func (Transaction) TableName() string {
 	return "transactions"
 }
 
+// AmountDue is the part of the total not paid with loyalty points
+func (t Transaction) AmountDue() float64 {
+	return t.TotalAmount - t.PointsAmount
+}
+
+// BalanceDue is the part of the amount due not paid with gift cards or store credit
+func (t Transaction) BalanceDue() float64 {
+	return t.AmountDue() - t.TenderedAmount
+}
+
 // CheckoutItem represents a single item in the checkout request
 type CheckoutItem struct {
 	ProductID int `json:"product_id"`
+	VariantID int `json:"variant_id,omitempty"`
 	Quantity  int `json:"quantity"`
 }
 
 // CheckoutRequest represents the checkout request payload
 type CheckoutRequest struct {
 	Items []CheckoutItem `json:"items"`
+	// CustomerID optionally attaches the transaction to a customer
+	CustomerID *uint `json:"customer_id,omitempty"`
+	// RedeemPoints pays part of the total with the loyalty points of the customer
+	RedeemPoints int `json:"redeem_points,omitempty"`
+	// Tenders pay part of the amount due with gift cards or store credit
+	Tenders []Tender `json:"tenders,omitempty"`
+	// PaymentMethod pays the rest, cash by default
+	PaymentMethod string `json:"payment_method,omitempty"`
+	// AmountPaid is the cash handed over, the difference is given back as
+	// change. Zero means the exact amount.
+	AmountPaid float64 `json:"amount_paid,omitempty"`
 }
 
 // BestSellingProduct represents the best selling product info
@@ -4,11 +4,24 @@ type TransactionDetail struct {
 	ID            uint    `gorm:"primaryKey" json:"id"`
 	TransactionID uint    `gorm:"not null;index" json:"transaction_id"`
 	ProductID     uint    `gorm:"not null;index" json:"product_id"`
+	VariantID     *uint   `gorm:"index" json:"variant_id,omitempty"`
 	Quantity      int     `gorm:"not null" json:"quantity"`
-	Subtotal      float64 `gorm:"type:decimal(10,2);not null" json:"subtotal"`
+	UnitPrice     float64 `gorm:"type:decimal(10,2);not null;default:0" json:"unit_price"`
+	Discount      float64 `gorm:"type:decimal(10,2);not null;default:0" json:"discount"`
+	// Subtotal is the line amount after the promotion discount
+	Subtotal float64 `gorm:"type:decimal(10,2);not null" json:"subtotal"`
+	// PriceListID is the price list that set the unit price, if any
+	PriceListID *uint `gorm:"index" json:"price_list_id,omitempty"`
+	// PriceTierID is the customer group tier that set the unit price, if any
+	PriceTierID *uint `gorm:"index" json:"price_tier_id,omitempty"`
+	// PromotionID is the promotion the line took part in, if any
+	PromotionID *uint `gorm:"index" json:"promotion_id,omitempty"`
 
-	Transaction Transaction `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
-	Product     Product     `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Transaction Transaction     `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+	Product     Product         `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Variant     *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"`
+
+	BundleComponents []TransactionBundleComponent `gorm:"foreignKey:TransactionDetailID;constraint:OnDelete:CASCADE" json:"bundle_components,omitempty"`
 }
 
 func (TransactionDetail) TableName() string {
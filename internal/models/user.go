@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Roles of a user, from most to least privileged
+const (
+	RoleAdmin   = "admin"
+	RoleManager = "manager"
+	RoleCashier = "cashier"
+	RoleViewer  = "viewer"
+)
+
+// Roles lists the valid user roles
+var Roles = []string{RoleAdmin, RoleManager, RoleCashier, RoleViewer}
+
+// User is a staff account that signs in to the API. Username is recorded as
+// the actor of the changes the user makes.
+type User struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	Username     string         `gorm:"size:100;not null;uniqueIndex" json:"username"`
+	Name         string         `gorm:"size:200" json:"name,omitempty"`
+	PasswordHash string         `gorm:"size:255;not null" json:"-"`
+	Role         string         `gorm:"size:20;not null;index" json:"role"`
+	Active       bool           `gorm:"not null;default:true" json:"active"`
+	LastLoginAt  *time.Time     `json:"last_login_at,omitempty"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (User) TableName() string {
+	return "users"
+}
+
+// LoginResult is the bearer token issued at sign in and the signed in user
+type LoginResult struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
+}
@@ -0,0 +1,59 @@
+package receipt
+
+import (
+	"fmt"
+	"gocats/internal/models"
+	"strings"
+)
+
+// DayReport renders an X- or Z-report as plain text that fits the paper width,
+// for printing at the till and reprinting stored Z-reports
+func (r *Renderer) DayReport(report *models.DayReport, paper Paper) []byte {
+	v := textView{Columns: paper.Columns}
+	var lines []string
+	add := func(line string) { lines = append(lines, line) }
+
+	if r.cfg.Store.Name != "" {
+		add(v.Center(r.cfg.Store.Name))
+	}
+	if report.Type == models.ReportZ {
+		add(v.Center(fmt.Sprintf("Z-REPORT #%d", report.Number)))
+	} else {
+		add(v.Center("X-REPORT"))
+	}
+	add(v.Rule())
+	add(v.Row("From", report.PeriodStart.Format("02/01/2006 15:04")))
+	add(v.Row("To", report.PeriodEnd.Format("02/01/2006 15:04")))
+	add(v.Row("Transactions", fmt.Sprint(report.TransactionCount)))
+	add(v.Rule())
+	add(v.Row("Gross sales", Money(report.GrossSales)))
+	add(v.Row("Discounts", Money(-report.Discounts)))
+	add(v.Row(fmt.Sprintf("Refunds (%d)", report.RefundCount), Money(-report.Refunds)))
+	add(v.Row(fmt.Sprintf("Voids (%d)", report.VoidCount), Money(-report.Voids)))
+	add(v.Row("NET SALES", Money(report.NetSales)))
+	add(v.Row("Tax included", Money(report.Taxes)))
+	add(v.Rule())
+
+	add("Payments")
+	for _, payment := range report.Payments {
+		add(v.Row(fmt.Sprintf("  %s (%d)", paymentName(payment.Method), payment.Count), Money(payment.Amount)))
+	}
+	add(v.Row("  Cash refunds", Money(-report.CashRefunds)))
+	add(v.Rule())
+
+	add("Categories")
+	for _, category := range report.Categories {
+		name := category.Name
+		if name == "" {
+			name = fmt.Sprintf("Category %d", category.CategoryID)
+		}
+		add(v.Row(fmt.Sprintf("  %s x%d", name, category.Quantity), Money(category.Amount)))
+	}
+	add(v.Rule())
+
+	if report.Type == models.ReportZ {
+		add(v.Row("Closed by", report.CreatedBy))
+		add(v.Row("Closed at", report.CreatedAt.Format("02/01/2006 15:04")))
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
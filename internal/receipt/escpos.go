@@ -0,0 +1,214 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ESC/POS control codes
+const (
+	esc = 0x1b
+	gs  = 0x1d
+)
+
+// Text alignments of the ESC/POS encoder
+const (
+	AlignLeft   = 0
+	AlignCenter = 1
+	AlignRight  = 2
+)
+
+// Encoder writes ESC/POS commands for thermal receipt printers. Text is sent
+// as ASCII; characters the default code page cannot print become "?".
+type Encoder struct {
+	buf bytes.Buffer
+}
+
+// NewEncoder starts a byte stream that resets the printer
+func NewEncoder() *Encoder {
+	e := &Encoder{}
+	e.buf.Write([]byte{esc, '@'})
+	return e
+}
+
+// Bytes returns the encoded stream
+func (e *Encoder) Bytes() []byte {
+	return e.buf.Bytes()
+}
+
+// Text writes s without a line feed
+func (e *Encoder) Text(s string) {
+	for _, r := range s {
+		switch {
+		case r == '\n' || (r >= 0x20 && r < 0x7f):
+			e.buf.WriteByte(byte(r))
+		default:
+			e.buf.WriteByte('?')
+		}
+	}
+}
+
+// Line writes s followed by a line feed
+func (e *Encoder) Line(s string) {
+	e.Text(s)
+	e.buf.WriteByte('\n')
+}
+
+// Bold turns emphasized printing on or off
+func (e *Encoder) Bold(on bool) {
+	e.buf.Write([]byte{esc, 'E', flag(on)})
+}
+
+// DoubleSize turns double width and height characters on or off
+func (e *Encoder) DoubleSize(on bool) {
+	size := byte(0x00)
+	if on {
+		size = 0x11
+	}
+	e.buf.Write([]byte{gs, '!', size})
+}
+
+// Align aligns the following lines to AlignLeft, AlignCenter or AlignRight
+func (e *Encoder) Align(alignment byte) {
+	e.buf.Write([]byte{esc, 'a', alignment})
+}
+
+// Feed prints the buffer and feeds n lines
+func (e *Encoder) Feed(n byte) {
+	e.buf.Write([]byte{esc, 'd', n})
+}
+
+// Cut feeds the paper to the cutter and makes a partial cut
+func (e *Encoder) Cut() {
+	e.buf.Write([]byte{gs, 'V', 66, 0})
+}
+
+// KickDrawer pulses pin 2 of the drawer kick-out connector to open the cash
+// drawer: 50ms on, 500ms off
+func (e *Encoder) KickDrawer() {
+	e.buf.Write([]byte{esc, 'p', 0, 25, 250})
+}
+
+// QRCode prints data as a model 2 QR code with the given module size in dots
+// (1-16) and error correction level M
+func (e *Encoder) QRCode(data string, size byte) {
+	if size < 1 || size > 16 {
+		size = 6
+	}
+	// Select model 2
+	e.qr(65, 50, 0)
+	// Module size
+	e.qr(67, size)
+	// Error correction level M
+	e.qr(69, 49)
+	// Store the data in the symbol storage area
+	e.qr(80, append([]byte{48}, data...)...)
+	// Print the stored symbol
+	e.qr(81, 48)
+}
+
+// qr writes a GS ( k function of the QR code symbol (cn 49)
+func (e *Encoder) qr(function byte, params ...byte) {
+	n := len(params) + 2
+	e.buf.Write([]byte{gs, '(', 'k', byte(n % 256), byte(n / 256), 49, function})
+	e.buf.Write(params)
+}
+
+func flag(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
+
+// ESCPOS encodes a receipt for an ESC/POS printer loaded with the paper: a
+// bold header and total, a QR code of the receipt number and a cut. When
+// openDrawer is set the cash drawer is kicked open after printing.
+func (r *Renderer) ESCPOS(receipt *Receipt, paper Paper, openDrawer bool) []byte {
+	v := textView{Receipt: receipt, Columns: paper.Columns}
+	e := NewEncoder()
+
+	e.Align(AlignCenter)
+	if receipt.Store.Name != "" {
+		e.Bold(true)
+		e.DoubleSize(true)
+		e.Line(truncate(receipt.Store.Name, paper.Columns/2))
+		e.DoubleSize(false)
+		e.Bold(false)
+	}
+	for _, line := range receipt.Store.AddressLines() {
+		e.Line(truncate(line, paper.Columns))
+	}
+	if receipt.Store.Phone != "" {
+		e.Line(truncate("Tel. "+receipt.Store.Phone, paper.Columns))
+	}
+	if receipt.Store.TaxID != "" {
+		e.Line(truncate("Tax ID "+receipt.Store.TaxID, paper.Columns))
+	}
+	e.Align(AlignLeft)
+
+	e.Line(v.Rule())
+	e.Line(v.Row(receipt.Number, receipt.Date.Format("02/01/2006 15:04")))
+	if receipt.Customer != "" {
+		e.Line(v.Row("Customer", receipt.Customer))
+	}
+	e.Line(v.Rule())
+	for _, line := range receipt.Lines {
+		e.Line(v.Wrap(line.Name))
+		if line.Detail != "" {
+			e.Line(v.Wrap("  " + line.Detail))
+		}
+		e.Line(v.Row(fmt.Sprintf("  %d x %s", line.Quantity, Money(line.UnitPrice)), Money(line.Gross())))
+		if line.Discount != 0 {
+			e.Line(v.Row("  Discount", Money(-line.Discount)))
+		}
+	}
+	e.Line(v.Rule())
+	e.Line(v.Row("Subtotal", Money(receipt.Subtotal)))
+	if receipt.Discount != 0 {
+		e.Line(v.Row("Discount", Money(-receipt.Discount)))
+	}
+	e.Bold(true)
+	e.Line(v.Row("TOTAL", Money(receipt.Total)))
+	e.Bold(false)
+	if receipt.Tax != 0 {
+		e.Line(v.Row("Tax included", Money(receipt.Tax)))
+	}
+	e.Line(v.Rule())
+	for _, payment := range receipt.Payments {
+		e.Line(v.Row(payment.Method, Money(payment.Amount)))
+	}
+	if receipt.Change != 0 {
+		e.Line(v.Row("Change", Money(receipt.Change)))
+	}
+
+	e.Align(AlignCenter)
+	if receipt.Refunded {
+		e.Line(v.Rule())
+		e.Bold(true)
+		e.Line("*** REFUNDED ***")
+		e.Bold(false)
+	}
+	if receipt.Footer != "" {
+		e.Line(v.Rule())
+		e.Line(strings.TrimSpace(v.Wrap(receipt.Footer)))
+	}
+	e.Feed(1)
+	e.QRCode(receipt.Number, qrSize(paper))
+	e.Feed(3)
+	e.Cut()
+	if openDrawer {
+		e.KickDrawer()
+	}
+
+	return e.Bytes()
+}
+
+// qrSize is the QR code module size that fits the paper comfortably
+func qrSize(paper Paper) byte {
+	if paper.Columns < Paper80.Columns {
+		return 5
+	}
+	return 6
+}
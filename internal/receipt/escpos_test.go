@@ -0,0 +1,92 @@
+package receipt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncoderCommands(t *testing.T) {
+	e := NewEncoder()
+	e.Bold(true)
+	e.Align(AlignCenter)
+	e.Line("Café")
+	e.Bold(false)
+	e.Align(AlignRight)
+	e.Cut()
+	e.KickDrawer()
+
+	want := []byte{
+		esc, '@',
+		esc, 'E', 1,
+		esc, 'a', 1,
+		'C', 'a', 'f', '?', '\n',
+		esc, 'E', 0,
+		esc, 'a', 2,
+		gs, 'V', 66, 0,
+		esc, 'p', 0, 25, 250,
+	}
+	if got := e.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("Bytes() = % x, want % x", got, want)
+	}
+}
+
+func TestEncoderQRCode(t *testing.T) {
+	data := strings.Repeat("A", 300)
+	e := NewEncoder()
+	e.QRCode(data, 0)
+	got := e.Bytes()[2:]
+
+	// Every function is GS ( k pL pH cn fn params, where pL + pH*256 counts
+	// cn, fn and the params
+	want := [][]byte{
+		{gs, '(', 'k', 4, 0, 49, 65, 50, 0},
+		{gs, '(', 'k', 3, 0, 49, 67, 6},
+		{gs, '(', 'k', 3, 0, 49, 69, 49},
+		append([]byte{gs, '(', 'k', 47, 1, 49, 80, 48}, data...),
+		{gs, '(', 'k', 3, 0, 49, 81, 48},
+	}
+	for i, function := range want {
+		if !bytes.HasPrefix(got, function) {
+			t.Fatalf("function %d = % x, want % x", i, got[:min(len(got), len(function))], function)
+		}
+		got = got[len(function):]
+	}
+	if len(got) != 0 {
+		t.Errorf("trailing bytes % x", got)
+	}
+}
+
+func TestESCPOSDrawerKick(t *testing.T) {
+	r, err := NewRenderer(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := &Receipt{
+		Store:    Store{Name: "Corner Shop"},
+		Number:   "TRX-1",
+		Date:     time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC),
+		Lines:    []Line{{Name: "Coffee", Quantity: 2, UnitPrice: 1.5}},
+		Subtotal: 3,
+		Total:    3,
+	}
+	kick := []byte{esc, 'p', 0, 25, 250}
+	cut := []byte{gs, 'V', 66, 0}
+
+	data := r.ESCPOS(receipt, Paper58, false)
+	if !bytes.HasSuffix(data, cut) {
+		t.Errorf("receipt does not end with a cut: % x", data[len(data)-8:])
+	}
+	if bytes.Contains(data, kick) {
+		t.Error("drawer kicked without openDrawer")
+	}
+	if !bytes.Contains(data, []byte("Corner Shop\n")) || !bytes.Contains(data, []byte("TRX-1")) {
+		t.Error("store name or receipt number missing")
+	}
+
+	data = r.ESCPOS(receipt, Paper58, true)
+	if !bytes.HasSuffix(data, append(cut, kick...)) {
+		t.Errorf("receipt does not end with a cut and drawer kick: % x", data[len(data)-12:])
+	}
+}
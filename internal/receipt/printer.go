@@ -0,0 +1,56 @@
+package receipt
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrNoPrinter is returned when no network printer is configured
+var ErrNoPrinter = errors.New("receipt printer is not configured")
+
+// PrinterConfig is the network printer receipts are sent to, usually port 9100
+// of an ESC/POS printer. Printing is disabled while Address is empty.
+type PrinterConfig struct {
+	Address string
+	Timeout time.Duration
+}
+
+// Printer sends raw ESC/POS byte streams to a network printer over TCP
+type Printer struct {
+	cfg PrinterConfig
+}
+
+func NewPrinter(cfg PrinterConfig) *Printer {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &Printer{cfg: cfg}
+}
+
+// Enabled reports whether a printer address is configured
+func (p *Printer) Enabled() bool {
+	return p.cfg.Address != ""
+}
+
+// Send opens a connection to the printer, writes data and closes it
+func (p *Printer) Send(data []byte) error {
+	if !p.Enabled() {
+		return ErrNoPrinter
+	}
+
+	conn, err := net.DialTimeout("tcp", p.cfg.Address, p.cfg.Timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to printer %s: %w", p.cfg.Address, err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(p.cfg.Timeout)); err != nil {
+		return err
+	}
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("failed to send to printer %s: %w", p.cfg.Address, err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package receipt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPrinterSend(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	e := NewEncoder()
+	e.Line("hello")
+	e.Cut()
+	p := NewPrinter(PrinterConfig{Address: ln.Addr().String(), Timeout: time.Second})
+	if err := p.Send(e.Bytes()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	select {
+	case data := <-received:
+		if !bytes.Equal(data, e.Bytes()) {
+			t.Errorf("printer received % x, want % x", data, e.Bytes())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("printer received nothing")
+	}
+}
+
+func TestPrinterSendErrors(t *testing.T) {
+	if err := NewPrinter(PrinterConfig{}).Send([]byte("x")); !errors.Is(err, ErrNoPrinter) {
+		t.Errorf("Send without address = %v, want ErrNoPrinter", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := ln.Addr().String()
+	ln.Close()
+
+	p := NewPrinter(PrinterConfig{Address: address, Timeout: time.Second})
+	if err := p.Send([]byte("x")); err == nil {
+		t.Error("Send to a closed port succeeded")
+	}
+}
@@ -0,0 +1,225 @@
+package receipt
+
+import (
+	"fmt"
+	"gocats/internal/models"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Config is the store header and footer printed on every receipt. TemplateDir
+// optionally holds receipt.txt.tmpl and receipt.html.tmpl that replace the
+// built-in templates.
+type Config struct {
+	Store       Store
+	Footer      string
+	TemplateDir string
+}
+
+type Store struct {
+	Name    string
+	Address string
+	Phone   string
+	TaxID   string
+}
+
+// AddressLines splits a multi-line address, lines are separated by "|" or newlines
+func (s Store) AddressLines() []string {
+	var lines []string
+	for _, line := range strings.FieldsFunc(s.Address, func(r rune) bool { return r == '|' || r == '\n' }) {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Paper is a thermal paper roll with the number of characters that fit on a line
+type Paper struct {
+	MM      int
+	Columns int
+}
+
+var (
+	Paper58 = Paper{MM: 58, Columns: 32}
+	Paper80 = Paper{MM: 80, Columns: 48}
+)
+
+// PaperFor returns the paper of the given width in millimetres
+func PaperFor(mm int) (Paper, error) {
+	switch mm {
+	case 58:
+		return Paper58, nil
+	case 80:
+		return Paper80, nil
+	default:
+		return Paper{}, fmt.Errorf("paper width must be 58 or 80, got %d", mm)
+	}
+}
+
+// Receipt is a transaction laid out for printing
+type Receipt struct {
+	Store    Store
+	Number   string
+	Date     time.Time
+	Customer string
+	Lines    []Line
+	Subtotal float64
+	Discount float64
+	Total    float64
+	Tax      float64
+	Payments []Payment
+	Change   float64
+	Refunded bool
+	Footer   string
+}
+
+// Line is a sold product. Detail names the variant, if any.
+type Line struct {
+	Name      string
+	Detail    string
+	Quantity  int
+	UnitPrice float64
+	Discount  float64
+	Amount    float64
+}
+
+// Gross is the line amount before discount
+func (l Line) Gross() float64 {
+	return math.Round(l.UnitPrice*float64(l.Quantity)*100) / 100
+}
+
+type Payment struct {
+	Method string
+	Amount float64
+}
+
+// New lays out a transaction loaded with its lines, products, variants,
+// customer, tenders and refund
+func New(transaction *models.Transaction, cfg Config) *Receipt {
+	r := &Receipt{
+		Store:    cfg.Store,
+		Number:   fmt.Sprintf("#%d", transaction.ID),
+		Date:     transaction.CreatedAt,
+		Total:    transaction.TotalAmount,
+		Tax:      transaction.TaxAmount,
+		Change:   transaction.Change,
+		Refunded: transaction.Refund != nil,
+		Footer:   cfg.Footer,
+	}
+	if transaction.InvoiceNumber != nil {
+		r.Number = *transaction.InvoiceNumber
+	}
+	if transaction.Customer != nil {
+		r.Customer = transaction.Customer.Name
+	}
+
+	for _, detail := range transaction.TransactionDetails {
+		line := Line{
+			Name:      detail.Product.Name,
+			Quantity:  detail.Quantity,
+			UnitPrice: detail.UnitPrice,
+			Discount:  detail.Discount,
+			Amount:    detail.Subtotal,
+		}
+		if detail.Variant != nil {
+			line.Detail = variantName(*detail.Variant)
+		}
+		r.Lines = append(r.Lines, line)
+		r.Subtotal += detail.UnitPrice * float64(detail.Quantity)
+		r.Discount += detail.Discount
+	}
+	r.Subtotal = math.Round(r.Subtotal*100) / 100
+	r.Discount = math.Round(r.Discount*100) / 100
+
+	if transaction.PointsAmount > 0 {
+		r.Payments = append(r.Payments, Payment{
+			Method: fmt.Sprintf("Points (%d)", transaction.PointsRedeemed),
+			Amount: transaction.PointsAmount,
+		})
+	}
+	for _, tender := range transaction.Tenders {
+		r.Payments = append(r.Payments, Payment{Method: tenderName(tender), Amount: tender.Amount})
+	}
+	if transaction.AmountPaid > 0 || len(r.Payments) == 0 {
+		r.Payments = append(r.Payments, Payment{
+			Method: paymentName(transaction.PaymentMethod),
+			Amount: transaction.AmountPaid,
+		})
+	}
+
+	return r
+}
+
+// variantName joins the option values of a variant in option name order, e.g. "L / Red"
+func variantName(variant models.ProductVariant) string {
+	if len(variant.Options) == 0 {
+		return variant.SKU
+	}
+	names := make([]string, 0, len(variant.Options))
+	for name := range variant.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = variant.Options[name]
+	}
+	return strings.Join(values, " / ")
+}
+
+// tenderName names a gift card by the last four characters of its code
+func tenderName(tender models.TransactionTender) string {
+	if tender.Type != models.AccountGiftCard {
+		return "Store credit"
+	}
+	if tender.Account == nil || tender.Account.Code == nil {
+		return "Gift card"
+	}
+	code := strings.ReplaceAll(*tender.Account.Code, "-", "")
+	if len(code) > 4 {
+		code = code[len(code)-4:]
+	}
+	return "Gift card ****" + code
+}
+
+func paymentName(method string) string {
+	switch method {
+	case "", models.PaymentCash:
+		return "Cash"
+	case models.PaymentCard:
+		return "Card"
+	case models.PaymentTransfer:
+		return "Transfer"
+	case models.AccountGiftCard:
+		return "Gift card"
+	case models.AccountStoreCredit:
+		return "Store credit"
+	case models.PaymentLoyaltyPoints:
+		return "Points"
+	default:
+		return strings.ToUpper(method[:1]) + method[1:]
+	}
+}
+
+// Money formats an amount with thousands separators and two decimals, e.g. 12,500.00
+func Money(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	s := fmt.Sprintf("%.2f", amount)
+	whole, fraction := s[:len(s)-3], s[len(s)-3:]
+
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String() + fraction
+}
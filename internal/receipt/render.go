@@ -0,0 +1,189 @@
+package receipt
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"unicode/utf8"
+)
+
+// Receipt formats served by the API
+const (
+	FormatText = "text"
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+	// FormatESCPOS is the raw byte stream of an ESC/POS thermal printer
+	FormatESCPOS = "escpos"
+)
+
+const (
+	textTemplateName = "receipt.txt.tmpl"
+	htmlTemplateName = "receipt.html.tmpl"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+var templateFuncs = map[string]interface{}{
+	"money": Money,
+	"neg":   func(amount float64) float64 { return -amount },
+}
+
+// Renderer lays out transactions as receipts and renders them with the text
+// and HTML templates of the store
+type Renderer struct {
+	cfg  Config
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// NewRenderer parses the receipt templates, preferring those in cfg.TemplateDir
+func NewRenderer(cfg Config) (*Renderer, error) {
+	textSource, err := readTemplate(cfg.TemplateDir, textTemplateName)
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.New(textTemplateName).Funcs(templateFuncs).Parse(textSource)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlSource, err := readTemplate(cfg.TemplateDir, htmlTemplateName)
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.New(htmlTemplateName).Funcs(templateFuncs).Parse(htmlSource)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Renderer{cfg: cfg, text: text, html: html}, nil
+}
+
+// readTemplate reads a template from dir, or the built-in one when dir is
+// empty or does not have it
+func readTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		source, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(source), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	source, err := defaultTemplates.ReadFile("templates/" + name)
+	return string(source), err
+}
+
+// Config returns the store header and footer the renderer prints
+func (r *Renderer) Config() Config {
+	return r.cfg
+}
+
+// Text renders a receipt as plain text that fits the paper width
+func (r *Renderer) Text(receipt *Receipt, paper Paper) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.text.Execute(&buf, textView{Receipt: receipt, Columns: paper.Columns}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// HTML renders a receipt as an HTML page
+func (r *Renderer) HTML(receipt *Receipt) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.html.Execute(&buf, receipt); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// PDF renders the text receipt on a PDF page as wide as the paper
+func (r *Renderer) PDF(receipt *Receipt, paper Paper) ([]byte, error) {
+	text, err := r.Text(receipt, paper)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimRight(string(text), "\n"), "\n")
+	return writePDF(lines, paper), nil
+}
+
+// textView gives the text template helpers that lay out text in Columns characters
+type textView struct {
+	*Receipt
+	Columns int
+}
+
+// Rule is a full-width separator line
+func (v textView) Rule() string {
+	return strings.Repeat("-", v.Columns)
+}
+
+// Center centers s on the line
+func (v textView) Center(s string) string {
+	s = truncate(s, v.Columns)
+	return strings.Repeat(" ", (v.Columns-utf8.RuneCountInString(s))/2) + s
+}
+
+// Row puts left at the start of the line and right at the end. When both do
+// not fit, left gets a line of its own and right is aligned on the next.
+func (v textView) Row(left, right string) string {
+	right = truncate(right, v.Columns)
+	gap := v.Columns - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	if gap < 1 {
+		return truncate(left, v.Columns) + "\n" + strings.Repeat(" ", v.Columns-utf8.RuneCountInString(right)) + right
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
+
+// Wrap breaks s into lines of at most Columns characters at spaces. Leading
+// spaces of s indent every line.
+func (v textView) Wrap(s string) string {
+	indent := s[:len(s)-len(strings.TrimLeft(s, " "))]
+	width := v.Columns - len(indent)
+	if width < 1 {
+		indent, width = "", v.Columns
+	}
+
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		for utf8.RuneCountInString(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return indent + strings.Join(lines, "\n"+indent)
+}
+
+func truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
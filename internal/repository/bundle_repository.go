@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"gocats/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type BundleRepository interface {
+	ReplaceItems(bundleID uint, items []models.BundleItem) error
+	FindItems(bundleID uint) ([]models.BundleItem, error)
+}
+
+type bundleRepository struct {
+	db *gorm.DB
+}
+
+func NewBundleRepository(db *gorm.DB) BundleRepository {
+	return &bundleRepository{db: db}
+}
+
+// ReplaceItems swaps the components of a bundle and marks the product as a bundle
+// (or back to a regular product when items is empty) in one DB transaction
+func (r *bundleRepository) ReplaceItems(bundleID uint, items []models.BundleItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bundle_id = ?", bundleID).Delete(&models.BundleItem{}).Error; err != nil {
+			return err
+		}
+
+		if len(items) > 0 {
+			for i := range items {
+				items[i].BundleID = bundleID
+			}
+			if err := tx.Omit("Component").Create(&items).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Product{}).Where("id = ?", bundleID).UpdateColumn("is_bundle", len(items) > 0).Error
+	})
+}
+
+func (r *bundleRepository) FindItems(bundleID uint) ([]models.BundleItem, error) {
+	var items []models.BundleItem
+	err := r.db.Preload("Component").Where("bundle_id = ?", bundleID).Order("id").Find(&items).Error
+	return items, err
+}
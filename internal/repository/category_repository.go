@@ -2,6 +2,7 @@ package repository
 
 import (
 	"gocats/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -9,9 +10,31 @@ import (
 type CategoryRepository interface {
 	Create(category *models.Category) error
 	FindByID(id uint) (*models.Category, error)
-	FindAll() ([]models.Category, error)
+	FindAll(opts QueryOptions) ([]models.Category, *Page, error)
 	Update(category *models.Category) error
 	Delete(id uint) error
+	FindArchived() ([]models.Category, error)
+	FindArchivedByID(id uint) (*models.Category, error)
+	FindArchivedByName(name string) (*models.Category, error)
+	Restore(id uint) error
+	PurgeArchived(before time.Time) (int64, error)
+	CountProducts(id uint) (active int64, archived int64, err error)
+	ReassignAndDelete(id, targetID uint) (int64, error)
+	DeleteWithProducts(id uint) (int64, error)
+	FindDescendantIDs(id uint) ([]uint, error)
+	CountChildren(id uint) (int64, error)
+	MoveProducts(tx *gorm.DB, fromIDs []uint, toID uint) (int64, error)
+	ReparentChildren(tx *gorm.DB, fromIDs []uint, toID uint) error
+	UpdateDescription(tx *gorm.DB, id uint, description string) error
+	DeleteMany(tx *gorm.DB, ids []uint, permanent bool) error
+	CreateMerge(tx *gorm.DB, merge *models.CategoryMerge) error
+	FindMerges(targetID uint) ([]models.CategoryMerge, error)
+}
+
+// categorySortColumns are the sort fields accepted by category list queries
+var categorySortColumns = map[string]string{
+	"id":   "categories.id",
+	"name": "categories.name",
 }
 
 type categoryRepository struct {
@@ -32,10 +55,27 @@ func (r *categoryRepository) FindByID(id uint) (*models.Category, error) {
 	return &category, err
 }
 
-func (r *categoryRepository) FindAll() ([]models.Category, error) {
+func (r *categoryRepository) FindAll(opts QueryOptions) ([]models.Category, *Page, error) {
+	query, page, err := paginate(r.db.Model(&models.Category{}), "categories", opts, categorySortColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var categories []models.Category
-	err := r.db.Find(&categories).Error
-	return categories, err
+	if err := query.Find(&categories).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if n := len(categories); n > 0 {
+		last := categories[n-1]
+		var value interface{} = last.ID
+		if opts.Sort == "name" {
+			value = last.Name
+		}
+		page.setNextCursor(n, value, last.ID)
+	}
+
+	return categories, page, nil
 }
 
 func (r *categoryRepository) Update(category *models.Category) error {
@@ -45,3 +85,147 @@ func (r *categoryRepository) Update(category *models.Category) error {
 func (r *categoryRepository) Delete(id uint) error {
 	return r.db.Delete(&models.Category{}, id).Error
 }
+
+// FindArchived returns soft-deleted categories, most recently archived first
+func (r *categoryRepository) FindArchived() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&categories).Error
+	return categories, err
+}
+
+func (r *categoryRepository) FindArchivedByID(id uint) (*models.Category, error) {
+	var category models.Category
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&category, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) FindArchivedByName(name string) (*models.Category, error) {
+	var category models.Category
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL AND name = ?", name).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) Restore(id uint) error {
+	return r.db.Unscoped().Model(&models.Category{}).Where("id = ?", id).UpdateColumn("deleted_at", nil).Error
+}
+
+// PurgeArchived permanently deletes categories archived before the given time
+// that no product (active or archived) still references
+func (r *categoryRepository) PurgeArchived(before time.Time) (int64, error) {
+	result := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM products p WHERE p.category_id = categories.id)").
+		Delete(&models.Category{})
+	return result.RowsAffected, result.Error
+}
+
+// CountProducts returns the number of active and archived products in a category
+func (r *categoryRepository) CountProducts(id uint) (active int64, archived int64, err error) {
+	if err = r.db.Model(&models.Product{}).Where("category_id = ?", id).Count(&active).Error; err != nil {
+		return 0, 0, err
+	}
+	err = r.db.Unscoped().Model(&models.Product{}).
+		Where("category_id = ? AND deleted_at IS NOT NULL", id).
+		Count(&archived).Error
+	return active, archived, err
+}
+
+// ReassignAndDelete moves all products (including archived ones) to the target
+// category and archives the category in one DB transaction
+func (r *categoryRepository) ReassignAndDelete(id, targetID uint) (int64, error) {
+	var moved int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.Product{}).
+			Where("category_id = ?", id).
+			UpdateColumn("category_id", targetID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = result.RowsAffected
+
+		return tx.Delete(&models.Category{}, id).Error
+	})
+	return moved, err
+}
+
+// DeleteWithProducts archives the category together with its active products
+// in one DB transaction
+func (r *categoryRepository) DeleteWithProducts(id uint) (int64, error) {
+	var archived int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("category_id = ?", id).Delete(&models.Product{})
+		if result.Error != nil {
+			return result.Error
+		}
+		archived = result.RowsAffected
+
+		return tx.Delete(&models.Category{}, id).Error
+	})
+	return archived, err
+}
+
+// FindDescendantIDs returns the ID of the category and of all its active
+// subcategories at any depth
+func (r *categoryRepository) FindDescendantIDs(id uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = ? AND deleted_at IS NULL
+			UNION
+			SELECT c.id FROM categories c
+			JOIN tree t ON c.parent_id = t.id
+			WHERE c.deleted_at IS NULL
+		)
+		SELECT id FROM tree`, id).
+		Scan(&ids).Error
+	return ids, err
+}
+
+func (r *categoryRepository) CountChildren(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// MoveProducts moves all products (including archived ones) of the given categories
+func (r *categoryRepository) MoveProducts(tx *gorm.DB, fromIDs []uint, toID uint) (int64, error) {
+	result := tx.Unscoped().Model(&models.Product{}).
+		Where("category_id IN ?", fromIDs).
+		UpdateColumn("category_id", toID)
+	return result.RowsAffected, result.Error
+}
+
+// ReparentChildren moves the subcategories (including archived ones) of the given categories
+func (r *categoryRepository) ReparentChildren(tx *gorm.DB, fromIDs []uint, toID uint) error {
+	return tx.Unscoped().Model(&models.Category{}).
+		Where("parent_id IN ?", fromIDs).
+		UpdateColumn("parent_id", toID).Error
+}
+
+func (r *categoryRepository) UpdateDescription(tx *gorm.DB, id uint, description string) error {
+	return tx.Model(&models.Category{}).Where("id = ?", id).UpdateColumn("description", description).Error
+}
+
+// DeleteMany archives the categories, or removes them for good when permanent is set
+func (r *categoryRepository) DeleteMany(tx *gorm.DB, ids []uint, permanent bool) error {
+	if permanent {
+		tx = tx.Unscoped()
+	}
+	return tx.Delete(&models.Category{}, ids).Error
+}
+
+func (r *categoryRepository) CreateMerge(tx *gorm.DB, merge *models.CategoryMerge) error {
+	return tx.Create(merge).Error
+}
+
+func (r *categoryRepository) FindMerges(targetID uint) ([]models.CategoryMerge, error) {
+	var merges []models.CategoryMerge
+	err := r.db.Where("target_id = ?", targetID).Order("created_at DESC").Find(&merges).Error
+	return merges, err
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"gocats/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CustomerGroupRepository interface {
+	Create(group *models.CustomerGroup) error
+	FindAll() ([]models.CustomerGroup, error)
+	FindByID(id uint) (*models.CustomerGroup, error)
+	Update(group *models.CustomerGroup) error
+	Delete(id uint) error
+	FindTiersByCustomerID(customerID uint) ([]models.PriceTier, error)
+}
+
+type customerGroupRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomerGroupRepository(db *gorm.DB) CustomerGroupRepository {
+	return &customerGroupRepository{db: db}
+}
+
+// orderTiers sorts tiers by product and ascending minimum quantity
+func orderTiers(db *gorm.DB) *gorm.DB {
+	return db.Order("product_id, min_quantity")
+}
+
+func (r *customerGroupRepository) Create(group *models.CustomerGroup) error {
+	return r.db.Create(group).Error
+}
+
+func (r *customerGroupRepository) FindAll() ([]models.CustomerGroup, error) {
+	var groups []models.CustomerGroup
+	err := r.db.Preload("Tiers", orderTiers).Order("name").Find(&groups).Error
+	return groups, err
+}
+
+func (r *customerGroupRepository) FindByID(id uint) (*models.CustomerGroup, error) {
+	var group models.CustomerGroup
+	if err := r.db.Preload("Tiers", orderTiers).First(&group, id).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// Update saves the group and replaces its tiers in one DB transaction
+func (r *customerGroupRepository) Update(group *models.CustomerGroup) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("customer_group_id = ?", group.ID).Delete(&models.PriceTier{}).Error; err != nil {
+			return err
+		}
+		for i := range group.Tiers {
+			group.Tiers[i].ID = 0
+			group.Tiers[i].CustomerGroupID = group.ID
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(group).Error
+	})
+}
+
+// Delete removes the group and its tiers; its customers go back to regular prices
+func (r *customerGroupRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Customer{}).Where("customer_group_id = ?", id).
+			Update("customer_group_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("customer_group_id = ?", id).Delete(&models.PriceTier{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.CustomerGroup{}, id).Error
+	})
+}
+
+// FindTiersByCustomerID returns the price tiers of the group of a customer,
+// none when the customer has no group
+func (r *customerGroupRepository) FindTiersByCustomerID(customerID uint) ([]models.PriceTier, error) {
+	var tiers []models.PriceTier
+	err := r.db.Joins("JOIN customers ON customers.customer_group_id = price_tiers.customer_group_id").
+		Where("customers.id = ? AND customers.deleted_at IS NULL", customerID).
+		Order("price_tiers.product_id, price_tiers.min_quantity").
+		Find(&tiers).Error
+	return tiers, err
+}
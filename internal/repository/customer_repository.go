@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"gocats/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CustomerRepository interface {
+	Create(customer *models.Customer) error
+	FindAll(search string, opts QueryOptions) ([]models.Customer, *Page, error)
+	FindByID(id uint) (*models.Customer, error)
+	Update(customer *models.Customer) error
+	Delete(id uint) error
+	GetStats(customerID uint) (*models.CustomerStats, error)
+	FindTransactions(customerID uint, opts QueryOptions) ([]models.Transaction, *Page, error)
+}
+
+// customerSortColumns are the sort fields accepted by customer list queries
+var customerSortColumns = map[string]string{
+	"id":   "customers.id",
+	"name": "customers.name",
+}
+
+// customerTransactionSortColumns are the sort fields accepted by purchase history queries
+var customerTransactionSortColumns = map[string]string{
+	"id":           "transactions.id",
+	"created_at":   "transactions.created_at",
+	"total_amount": "transactions.total_amount",
+}
+
+type customerRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomerRepository(db *gorm.DB) CustomerRepository {
+	return &customerRepository{db: db}
+}
+
+func (r *customerRepository) Create(customer *models.Customer) error {
+	return r.db.Create(customer).Error
+}
+
+// FindAll returns a page of customers whose name, phone or email contains search
+func (r *customerRepository) FindAll(search string, opts QueryOptions) ([]models.Customer, *Page, error) {
+	query := r.db.Model(&models.Customer{})
+	if search != "" {
+		pattern := "%" + search + "%"
+		query = query.Where("customers.name ILIKE ? OR customers.phone ILIKE ? OR customers.email ILIKE ?", pattern, pattern, pattern)
+	}
+
+	query, page, err := paginate(query, "customers", opts, customerSortColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var customers []models.Customer
+	if err := query.Find(&customers).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if n := len(customers); n > 0 {
+		last := customers[n-1]
+		var value interface{} = last.ID
+		if opts.Sort == "name" {
+			value = last.Name
+		}
+		page.setNextCursor(n, value, last.ID)
+	}
+
+	return customers, page, nil
+}
+
+func (r *customerRepository) FindByID(id uint) (*models.Customer, error) {
+	var customer models.Customer
+	if err := r.db.Preload("CustomerGroup").First(&customer, id).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// Update saves the customer fields, the group is only changed through CustomerGroupID
+func (r *customerRepository) Update(customer *models.Customer) error {
+	return r.db.Omit("CustomerGroup").Save(customer).Error
+}
+
+// Delete archives the customer, their transactions keep referencing them
+func (r *customerRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Customer{}, id).Error
+}
+
+// GetStats returns the number of transactions, lifetime value and last purchase time of a customer
+func (r *customerRepository) GetStats(customerID uint) (*models.CustomerStats, error) {
+	var stats models.CustomerStats
+	err := r.db.Model(&models.Transaction{}).
+		Select("COUNT(id) AS transaction_count, COALESCE(SUM(total_amount), 0) AS lifetime_value, MAX(created_at) AS last_purchase_at").
+		Where("customer_id = ?", customerID).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// FindTransactions returns a page of the transactions of a customer, newest first by default
+func (r *customerRepository) FindTransactions(customerID uint, opts QueryOptions) ([]models.Transaction, *Page, error) {
+	if opts.Sort == "" {
+		opts.Sort, opts.Desc = "created_at", true
+	}
+
+	query, page, err := paginate(r.db.Model(&models.Transaction{}).Where("transactions.customer_id = ?", customerID),
+		"transactions", opts, customerTransactionSortColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var transactions []models.Transaction
+	err = query.Preload("TransactionDetails.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("TransactionDetails.Variant").
+		Find(&transactions).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if n := len(transactions); n > 0 {
+		last := transactions[n-1]
+		var value interface{}
+		switch opts.Sort {
+		case "created_at":
+			value = last.CreatedAt
+		case "total_amount":
+			value = last.TotalAmount
+		default:
+			value = last.ID
+		}
+		page.setNextCursor(n, value, last.ID)
+	}
+
+	return transactions, page, nil
+}
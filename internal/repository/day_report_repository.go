@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"gocats/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DayReportRepository interface {
+	LockForClosing(tx *gorm.DB) error
+	FindLastZ(tx *gorm.DB) (*models.DayReport, error)
+	Summarize(tx *gorm.DB, afterTransactionID, afterRefundID uint, report *models.DayReport) error
+	Create(tx *gorm.DB, report *models.DayReport) error
+	FindAll(opts QueryOptions) ([]models.DayReport, *Page, error)
+	FindByNumber(number int64) (*models.DayReport, error)
+}
+
+// dayReportSortColumns are the sort fields accepted by Z-report list queries
+var dayReportSortColumns = map[string]string{
+	"id":     "day_reports.id",
+	"number": "day_reports.number",
+}
+
+type dayReportRepository struct {
+	db *gorm.DB
+}
+
+func NewDayReportRepository(db *gorm.DB) DayReportRepository {
+	return &dayReportRepository{db: db}
+}
+
+// LockForClosing serializes Z-reports and waits for checkouts and refunds in
+// flight to commit, then holds new ones back until tx ends. Every transaction
+// and refund is either in the closing report or gets a higher ID than it covers.
+func (r *dayReportRepository) LockForClosing(tx *gorm.DB) error {
+	if err := tx.Exec("LOCK TABLE day_reports IN EXCLUSIVE MODE").Error; err != nil {
+		return err
+	}
+	return tx.Exec("LOCK TABLE transactions, refunds IN SHARE MODE").Error
+}
+
+// FindLastZ returns the Z-report with the highest number
+func (r *dayReportRepository) FindLastZ(tx *gorm.DB) (*models.DayReport, error) {
+	var report models.DayReport
+	if err := tx.Order("number DESC").First(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Summarize fills the totals of report with the transactions and refunds after
+// the given IDs and sets its last IDs to the highest ones it covers. A zero
+// PeriodStart is set to the time of the first transaction covered.
+func (r *dayReportRepository) Summarize(tx *gorm.DB, afterTransactionID, afterRefundID uint, report *models.DayReport) error {
+	var last struct {
+		TransactionID uint
+		RefundID      uint
+	}
+	err := tx.Raw(`SELECT
+		(SELECT COALESCE(MAX(id), ?) FROM transactions WHERE id > ?) AS transaction_id,
+		(SELECT COALESCE(MAX(id), ?) FROM refunds WHERE id > ?) AS refund_id`,
+		afterTransactionID, afterTransactionID, afterRefundID, afterRefundID).
+		Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	report.LastTransactionID = last.TransactionID
+	report.LastRefundID = last.RefundID
+
+	from, to := afterTransactionID, last.TransactionID
+
+	var sales struct {
+		TransactionCount int64
+		Taxes            float64
+		FirstSale        *time.Time
+	}
+	err = tx.Model(&models.Transaction{}).
+		Select("COUNT(id) AS transaction_count, COALESCE(SUM(tax_amount), 0) AS taxes, MIN(created_at) AS first_sale").
+		Where("id > ? AND id <= ?", from, to).
+		Scan(&sales).Error
+	if err != nil {
+		return err
+	}
+
+	var lines struct {
+		GrossSales float64
+		Discounts  float64
+	}
+	err = tx.Model(&models.TransactionDetail{}).
+		Select("COALESCE(SUM(unit_price * quantity), 0) AS gross_sales, COALESCE(SUM(discount), 0) AS discounts").
+		Where("transaction_id > ? AND transaction_id <= ?", from, to).
+		Scan(&lines).Error
+	if err != nil {
+		return err
+	}
+
+	// Returns of transactions sold in this period are voids, older ones refunds
+	var returns struct {
+		RefundCount int64
+		Refunds     float64
+		VoidCount   int64
+		Voids       float64
+		Taxes       float64
+		CashRefunds float64
+	}
+	err = tx.Raw(`SELECT
+			COUNT(*) FILTER (WHERE t.id <= ?) AS refund_count,
+			COALESCE(SUM(t.total_amount) FILTER (WHERE t.id <= ?), 0) AS refunds,
+			COUNT(*) FILTER (WHERE t.id > ?) AS void_count,
+			COALESCE(SUM(t.total_amount) FILTER (WHERE t.id > ?), 0) AS voids,
+			COALESCE(SUM(t.tax_amount), 0) AS taxes,
+			COALESCE(SUM(r.cash_amount), 0) AS cash_refunds
+		FROM refunds r
+		JOIN transactions t ON t.id = r.transaction_id
+		WHERE r.id > ? AND r.id <= ?`,
+		from, from, from, from, afterRefundID, last.RefundID).
+		Scan(&returns).Error
+	if err != nil {
+		return err
+	}
+
+	var payments models.PaymentMethodTotals
+	err = tx.Raw(`SELECT method, count, amount FROM (
+			SELECT payment_method AS method, COUNT(*) AS count, SUM(amount_paid - change) AS amount
+			FROM transactions WHERE id > ? AND id <= ? AND amount_paid > 0
+			GROUP BY payment_method
+			UNION ALL
+			SELECT type AS method, COUNT(DISTINCT transaction_id) AS count, SUM(amount) AS amount
+			FROM transaction_tenders WHERE transaction_id > ? AND transaction_id <= ?
+			GROUP BY type
+			UNION ALL
+			SELECT ? AS method, COUNT(*) AS count, SUM(points_amount) AS amount
+			FROM transactions WHERE id > ? AND id <= ? AND points_amount > 0
+			HAVING COUNT(*) > 0
+		) payments ORDER BY method`,
+		from, to, from, to, models.PaymentLoyaltyPoints, from, to).
+		Scan(&payments).Error
+	if err != nil {
+		return err
+	}
+
+	var categories models.CategoryTotals
+	err = tx.Raw(`SELECT p.category_id, COALESCE(c.name, '') AS name,
+			SUM(td.quantity) AS quantity, SUM(td.subtotal) AS amount
+		FROM transaction_details td
+		JOIN products p ON p.id = td.product_id
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE td.transaction_id > ? AND td.transaction_id <= ?
+		GROUP BY p.category_id, c.name
+		ORDER BY amount DESC`,
+		from, to).
+		Scan(&categories).Error
+	if err != nil {
+		return err
+	}
+
+	if report.PeriodStart.IsZero() && sales.FirstSale != nil {
+		report.PeriodStart = *sales.FirstSale
+	}
+	report.TransactionCount = sales.TransactionCount
+	report.GrossSales = lines.GrossSales
+	report.Discounts = lines.Discounts
+	report.RefundCount = returns.RefundCount
+	report.Refunds = returns.Refunds
+	report.VoidCount = returns.VoidCount
+	report.Voids = returns.Voids
+	report.Taxes = sales.Taxes - returns.Taxes
+	report.CashRefunds = returns.CashRefunds
+	report.Payments = payments
+	report.Categories = categories
+	return nil
+}
+
+func (r *dayReportRepository) Create(tx *gorm.DB, report *models.DayReport) error {
+	return tx.Create(report).Error
+}
+
+// FindAll returns a page of Z-reports, newest first by default
+func (r *dayReportRepository) FindAll(opts QueryOptions) ([]models.DayReport, *Page, error) {
+	if opts.Sort == "" {
+		opts.Sort, opts.Desc = "number", true
+	}
+
+	query, page, err := paginate(r.db.Model(&models.DayReport{}), "day_reports", opts, dayReportSortColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reports []models.DayReport
+	if err := query.Find(&reports).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if n := len(reports); n > 0 {
+		last := reports[n-1]
+		var value interface{} = last.ID
+		if opts.Sort == "number" {
+			value = last.Number
+		}
+		page.setNextCursor(n, value, last.ID)
+	}
+
+	return reports, page, nil
+}
+
+func (r *dayReportRepository) FindByNumber(number int64) (*models.DayReport, error) {
+	var report models.DayReport
+	if err := r.db.Where("number = ?", number).First(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"gocats/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ImageRepository interface {
+	Create(image *models.ProductImage) error
+	FindByID(id uint) (*models.ProductImage, error)
+	FindByProductID(productID uint) ([]models.ProductImage, error)
+	NextPosition(productID uint) (int, error)
+	UpdatePositions(productID uint, imageIDs []uint) error
+	Delete(id uint) error
+}
+
+type imageRepository struct {
+	db *gorm.DB
+}
+
+func NewImageRepository(db *gorm.DB) ImageRepository {
+	return &imageRepository{db: db}
+}
+
+func (r *imageRepository) Create(image *models.ProductImage) error {
+	return r.db.Create(image).Error
+}
+
+func (r *imageRepository) FindByID(id uint) (*models.ProductImage, error) {
+	var image models.ProductImage
+	err := r.db.First(&image, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *imageRepository) FindByProductID(productID uint) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := r.db.Where("product_id = ?", productID).Order("position, id").Find(&images).Error
+	return images, err
+}
+
+func (r *imageRepository) NextPosition(productID uint) (int, error) {
+	var next int
+	err := r.db.Model(&models.ProductImage{}).
+		Select("COALESCE(MAX(position) + 1, 0)").
+		Where("product_id = ?", productID).
+		Scan(&next).Error
+	return next, err
+}
+
+// UpdatePositions sets the position of each image to its index in imageIDs
+func (r *imageRepository) UpdatePositions(productID uint, imageIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for position, id := range imageIDs {
+			err := tx.Model(&models.ProductImage{}).
+				Where("id = ? AND product_id = ?", id, productID).
+				UpdateColumn("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *imageRepository) Delete(id uint) error {
+	return r.db.Delete(&models.ProductImage{}, id).Error
+}
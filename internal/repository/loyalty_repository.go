@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"gocats/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type LoyaltyRepository interface {
+	CreateEntry(tx *gorm.DB, entry *models.LoyaltyEntry) error
+	GetBalance(tx *gorm.DB, customerID uint) (int, error)
+	FindByCustomerID(customerID uint) ([]models.LoyaltyEntry, error)
+	FindByTransactionID(tx *gorm.DB, transactionID uint) ([]models.LoyaltyEntry, error)
+}
+
+type loyaltyRepository struct {
+	db *gorm.DB
+}
+
+func NewLoyaltyRepository(db *gorm.DB) LoyaltyRepository {
+	return &loyaltyRepository{db: db}
+}
+
+func (r *loyaltyRepository) CreateEntry(tx *gorm.DB, entry *models.LoyaltyEntry) error {
+	return tx.Create(entry).Error
+}
+
+// GetBalance sums the ledger of a customer
+func (r *loyaltyRepository) GetBalance(tx *gorm.DB, customerID uint) (int, error) {
+	var balance int
+	err := tx.Model(&models.LoyaltyEntry{}).
+		Select("COALESCE(SUM(points), 0)").
+		Where("customer_id = ?", customerID).
+		Scan(&balance).Error
+	return balance, err
+}
+
+func (r *loyaltyRepository) FindByCustomerID(customerID uint) ([]models.LoyaltyEntry, error) {
+	var entries []models.LoyaltyEntry
+	err := r.db.Where("customer_id = ?", customerID).Order("created_at DESC, id DESC").Find(&entries).Error
+	return entries, err
+}
+
+func (r *loyaltyRepository) FindByTransactionID(tx *gorm.DB, transactionID uint) ([]models.LoyaltyEntry, error) {
+	var entries []models.LoyaltyEntry
+	err := tx.Where("transaction_id = ?", transactionID).Order("id").Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"gocats/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type PriceHistoryRepository interface {
+	UpdateProduct(product *models.Product, change *models.PriceChange) error
+	UpdateVariant(variant *models.ProductVariant, change *models.PriceChange) error
+	FindByProductID(productID uint) ([]models.PriceChange, error)
+	FindByDateRange(startDate, endDate string) ([]models.PriceChange, error)
+}
+
+type priceHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceHistoryRepository(db *gorm.DB) PriceHistoryRepository {
+	return &priceHistoryRepository{db: db}
+}
+
+// UpdateProduct saves the product and records its price change in one DB transaction
+func (r *priceHistoryRepository) UpdateProduct(product *models.Product, change *models.PriceChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(product).Error; err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// UpdateVariant saves the variant and records its price change in one DB transaction
+func (r *priceHistoryRepository) UpdateVariant(variant *models.ProductVariant, change *models.PriceChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(variant).Error; err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// FindByProductID returns the price changes of a product and its variants, newest first
+func (r *priceHistoryRepository) FindByProductID(productID uint) ([]models.PriceChange, error) {
+	var changes []models.PriceChange
+	err := r.withNames().
+		Where("price_changes.product_id = ?", productID).
+		Order("price_changes.changed_at DESC, price_changes.id DESC").
+		Find(&changes).Error
+	return changes, err
+}
+
+// FindByDateRange returns all price changes in the date range, oldest first
+func (r *priceHistoryRepository) FindByDateRange(startDate, endDate string) ([]models.PriceChange, error) {
+	var changes []models.PriceChange
+	err := r.withNames().
+		Where("DATE(price_changes.changed_at) >= ? AND DATE(price_changes.changed_at) <= ?", startDate, endDate).
+		Order("price_changes.changed_at, price_changes.id").
+		Find(&changes).Error
+	return changes, err
+}
+
+// withNames selects price changes with the product name and variant SKU,
+// including archived products and deleted variants
+func (r *priceHistoryRepository) withNames() *gorm.DB {
+	return r.db.Model(&models.PriceChange{}).
+		Select("price_changes.*, products.name AS product_name, COALESCE(product_variants.sku, '') AS sku").
+		Joins("LEFT JOIN products ON products.id = price_changes.product_id").
+		Joins("LEFT JOIN product_variants ON product_variants.id = price_changes.variant_id")
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"gocats/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PriceListRepository interface {
+	Create(priceList *models.PriceList) error
+	FindAll(activeAt *time.Time) ([]models.PriceList, error)
+	FindByID(id uint) (*models.PriceList, error)
+	Update(priceList *models.PriceList) error
+	Delete(id uint) error
+	FindActiveEntries(at time.Time) ([]models.PriceListEntry, error)
+}
+
+type priceListRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceListRepository(db *gorm.DB) PriceListRepository {
+	return &priceListRepository{db: db}
+}
+
+func (r *priceListRepository) Create(priceList *models.PriceList) error {
+	return r.db.Create(priceList).Error
+}
+
+// FindAll returns price lists by start time, optionally only those active at a time
+func (r *priceListRepository) FindAll(activeAt *time.Time) ([]models.PriceList, error) {
+	var priceLists []models.PriceList
+	query := r.db.Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+	if activeAt != nil {
+		query = query.Where("starts_at <= ? AND ends_at > ?", *activeAt, *activeAt)
+	}
+	err := query.Order("starts_at DESC, id").Find(&priceLists).Error
+	return priceLists, err
+}
+
+func (r *priceListRepository) FindByID(id uint) (*models.PriceList, error) {
+	var priceList models.PriceList
+	err := r.db.Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(&priceList, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &priceList, nil
+}
+
+// Update saves the price list and replaces its entries in one DB transaction
+func (r *priceListRepository) Update(priceList *models.PriceList) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("price_list_id = ?", priceList.ID).Delete(&models.PriceListEntry{}).Error; err != nil {
+			return err
+		}
+		for i := range priceList.Entries {
+			priceList.Entries[i].ID = 0
+			priceList.Entries[i].PriceListID = priceList.ID
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(priceList).Error
+	})
+}
+
+func (r *priceListRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("price_list_id = ?", id).Delete(&models.PriceListEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.PriceList{}, id).Error
+	})
+}
+
+// FindActiveEntries returns the entries of all price lists active at the given time
+func (r *priceListRepository) FindActiveEntries(at time.Time) ([]models.PriceListEntry, error) {
+	var entries []models.PriceListEntry
+	err := r.db.Preload("PriceList").
+		Joins("JOIN price_lists ON price_lists.id = price_list_entries.price_list_id").
+		Where("price_lists.starts_at <= ? AND price_lists.ends_at > ?", at, at).
+		Order("price_list_entries.id").
+		Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductFilter combines the criteria of a product list query. Zero values
+// leave a criterion unset.
+type ProductFilter struct {
+	Name        string
+	CategoryIDs []uint
+	MinPrice    *float64
+	MaxPrice    *float64
+	InStock     bool
+	// LowStock matches products whose available stock is at or below the threshold
+	LowStock    *int
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	Attributes  map[string]string
+}
+
+// availableStockSQL computes the sellable stock of a product the same way as
+// models.Product.AvailableStock: component availability for bundles, the sum
+// of variant stock for parent products, otherwise the stock column
+const availableStockSQL = `(CASE
+	WHEN products.is_bundle THEN COALESCE((
+		SELECT MIN(c.stock / bi.quantity) FROM bundle_items bi
+		JOIN products c ON c.id = bi.component_id AND c.deleted_at IS NULL
+		WHERE bi.bundle_id = products.id), 0)
+	WHEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id) THEN (
+		SELECT SUM(v.stock) FROM product_variants v WHERE v.product_id = products.id)
+	ELSE products.stock
+END)`
+
+// likeEscaper escapes the LIKE wildcards in user input, used with ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes s match literally in a LIKE pattern
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// apply adds the filter criteria to a products query
+func (f ProductFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.Name != "" {
+		query = query.Where(`products.name ILIKE ? ESCAPE '\'`, "%"+escapeLike(f.Name)+"%")
+	}
+	if len(f.CategoryIDs) > 0 {
+		query = query.Where("products.category_id IN ?", f.CategoryIDs)
+	}
+	if f.MinPrice != nil {
+		query = query.Where("products.price >= ?", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		query = query.Where("products.price <= ?", *f.MaxPrice)
+	}
+	if f.InStock {
+		query = query.Where(availableStockSQL + " > 0")
+	}
+	if f.LowStock != nil {
+		query = query.Where(availableStockSQL+" <= ?", *f.LowStock)
+	}
+	if f.CreatedFrom != nil {
+		query = query.Where("products.created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		query = query.Where("products.created_at < ?", *f.CreatedTo)
+	}
+	if f.UpdatedFrom != nil {
+		query = query.Where("products.updated_at >= ?", *f.UpdatedFrom)
+	}
+	if f.UpdatedTo != nil {
+		query = query.Where("products.updated_at < ?", *f.UpdatedTo)
+	}
+	for key, value := range f.Attributes {
+		query = query.Where("products.attributes ->> ? = ?", key, value)
+	}
+	return query
+}
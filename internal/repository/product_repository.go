@@ -2,6 +2,7 @@ package repository
 
 import (
 	"gocats/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -12,10 +13,23 @@ type ProductRepository interface {
 	Update(product *models.Product) error
 	Delete(id uint) error
 	List() ([]models.Product, error)
-	FindAll() ([]models.Product, error)
+	FindAll(opts QueryOptions) ([]models.Product, *Page, error)
+	Find(filter ProductFilter, opts QueryOptions) ([]models.Product, *Page, error)
+	Search(term string, filter ProductFilter, opts QueryOptions) ([]ProductSearchHit, *Page, error)
 	FindByID(id uint) (*models.Product, error)
-	FindByCategoryID(categoryID uint) ([]models.Product, error)
-	FindByName(name string) ([]models.Product, error)
+	FindArchived() ([]models.Product, error)
+	FindArchivedByID(id uint) (*models.Product, error)
+	Restore(id uint) error
+	FindPurgeable(before time.Time) ([]models.Product, error)
+	DeletePermanently(ids []uint) error
+}
+
+// productSortColumns are the sort fields accepted by product list queries
+var productSortColumns = map[string]string{
+	"id":    "products.id",
+	"name":  "products.name",
+	"price": "products.price",
+	"stock": availableStockSQL,
 }
 
 type productRepository struct {
@@ -35,36 +49,131 @@ func (r *productRepository) GetByID(id uint) (*models.Product, error) {
 }
 
 func (r *productRepository) List() ([]models.Product, error) {
-	return r.FindAll()
+	products, _, err := r.FindAll(QueryOptions{})
+	return products, err
 }
 
-func (r *productRepository) FindAll() ([]models.Product, error) {
-	var products []models.Product
-	err := r.db.Preload("Category").Find(&products).Error
-	return products, err
+func (r *productRepository) FindAll(opts QueryOptions) ([]models.Product, *Page, error) {
+	return r.Find(ProductFilter{}, opts)
+}
+
+// Find returns a page of products matching every criterion of the filter
+func (r *productRepository) Find(filter ProductFilter, opts QueryOptions) ([]models.Product, *Page, error) {
+	return r.findPage(filter.apply(r.db.Model(&models.Product{})), opts)
 }
 
 func (r *productRepository) FindByID(id uint) (*models.Product, error) {
 	var product models.Product
-	err := r.db.Preload("Category").First(&product, id).Error
+	err := r.withRelations().First(&product, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
-func (r *productRepository) FindByCategoryID(categoryID uint) ([]models.Product, error) {
+// findPage paginates a filtered product query and loads the relations of the page
+func (r *productRepository) findPage(query *gorm.DB, opts QueryOptions) ([]models.Product, *Page, error) {
+	query, page, err := paginate(query, "products", opts, productSortColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var products []models.Product
+	if err := preloadRelations(query).Find(&products).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if n := len(products); n > 0 {
+		last := products[n-1]
+		var value interface{}
+		switch opts.Sort {
+		case "name":
+			value = last.Name
+		case "price":
+			value = last.Price
+		case "stock":
+			value = last.AvailableStock()
+		default:
+			value = last.ID
+		}
+		page.setNextCursor(n, value, last.ID)
+	}
+
+	return products, page, nil
+}
+
+// FindArchived returns soft-deleted products, most recently archived first
+func (r *productRepository) FindArchived() ([]models.Product, error) {
 	var products []models.Product
-	err := r.db.Preload("Category").Where("category_id = ?", categoryID).Find(&products).Error
+	err := r.withRelations().Unscoped().
+		Preload("Category", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&products).Error
 	return products, err
 }
 
-func (r *productRepository) FindByName(name string) ([]models.Product, error) {
+func (r *productRepository) FindArchivedByID(id uint) (*models.Product, error) {
+	var product models.Product
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&product, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *productRepository) Restore(id uint) error {
+	return r.db.Unscoped().Model(&models.Product{}).Where("id = ?", id).UpdateColumn("deleted_at", nil).Error
+}
+
+// FindPurgeable returns products archived before the given time that can be
+// permanently deleted. Products referenced by transactions, bundles, price
+// history or promotions are kept so sales history stays intact and no
+// promotion loses its scope; a promotion without products applies to all.
+func (r *productRepository) FindPurgeable(before time.Time) ([]models.Product, error) {
 	var products []models.Product
-	err := r.db.Preload("Category").Where("name ILIKE ?", "%"+name+"%").Find(&products).Error
+	err := r.db.Unscoped().Preload("Images").
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM transaction_details td WHERE td.product_id = products.id)").
+		Where("NOT EXISTS (SELECT 1 FROM transaction_bundle_components tbc WHERE tbc.product_id = products.id)").
+		Where("NOT EXISTS (SELECT 1 FROM bundle_items bi WHERE bi.component_id = products.id)").
+		Where("NOT EXISTS (SELECT 1 FROM price_changes pc WHERE pc.product_id = products.id)").
+		Where("NOT EXISTS (SELECT 1 FROM promotions pr WHERE pr.product_ids @> to_jsonb(products.id))").
+		Find(&products).Error
 	return products, err
 }
 
+// DeletePermanently deletes products with their price list entries and
+// customer group price tiers in one DB transaction. Options, variants and
+// images are removed by the cascade.
+func (r *productRepository) DeletePermanently(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id IN ?", ids).Delete(&models.PriceListEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id IN ?", ids).Delete(&models.PriceTier{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Product{}, ids).Error
+	})
+}
+
+// withRelations preloads the category, bundle components, option types, variants and images of a product
+func (r *productRepository) withRelations() *gorm.DB {
+	return preloadRelations(r.db)
+}
+
+func preloadRelations(query *gorm.DB) *gorm.DB {
+	return query.Preload("Category").
+		Preload("BundleItems.Component").
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") })
+}
+
 func (r *productRepository) Update(product *models.Product) error {
 	return r.db.Save(product).Error
 }
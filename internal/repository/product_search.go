@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"fmt"
+	"gocats/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ProductSearchHit is a product matched by a search term with its relevance
+// and the matched text wrapped in <mark> tags
+type ProductSearchHit struct {
+	Product              models.Product
+	Rank                 float64
+	NameHighlight        string
+	DescriptionHighlight string
+	MatchedSKU           string
+}
+
+// productSearchMatch matches the term against the full-text vector of name and
+// description, and by trigram word similarity against the name, category name
+// and variant SKUs so typos still find results
+const productSearchMatch = `(
+	products.search_vector @@ websearch_to_tsquery('simple', @term)
+	OR @term <% products.name
+	OR @term <% categories.name
+	OR EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND (v.sku ILIKE @prefix ESCAPE '\' OR @term <% v.sku))
+)`
+
+// escapeHTMLSQL HTML-escapes a text expression, so the <mark> tags added by
+// ts_headline are the only markup of a highlight
+func escapeHTMLSQL(expr string) string {
+	return `replace(replace(replace(` + expr + `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')`
+}
+
+var productSearchColumns = `products.id,
+	ts_rank(products.search_vector, websearch_to_tsquery('simple', @term))
+		+ word_similarity(@term, products.name)
+		+ 0.5 * word_similarity(@term, coalesce(categories.name, ''))
+		+ CASE WHEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.sku ILIKE @sku ESCAPE '\') THEN 1 ELSE 0 END
+		AS rank,
+	ts_headline('simple', ` + escapeHTMLSQL("products.name") + `, websearch_to_tsquery('simple', @term),
+		'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS name_highlight,
+	ts_headline('simple', ` + escapeHTMLSQL("coalesce(products.description, '')") + `, websearch_to_tsquery('simple', @term),
+		'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MinWords=5, MaxWords=20') AS description_highlight,
+	coalesce((SELECT v.sku FROM product_variants v
+		WHERE v.product_id = products.id AND (v.sku ILIKE @prefix ESCAPE '\' OR @term <% v.sku)
+		ORDER BY word_similarity(@term, v.sku) DESC LIMIT 1), '') AS matched_sku`
+
+type productSearchRow struct {
+	ID                   uint
+	Rank                 float64
+	NameHighlight        string
+	DescriptionHighlight string
+	MatchedSKU           string
+}
+
+// Search returns products matching term and filter, most relevant first.
+// Results are ranked, so only offset pagination is supported.
+func (r *productRepository) Search(term string, filter ProductFilter, opts QueryOptions) ([]ProductSearchHit, *Page, error) {
+	if opts.Cursor != "" || (opts.Sort != "" && opts.Sort != "rank") {
+		return nil, nil, fmt.Errorf("%w: search results are sorted by rank and support offset pagination only", ErrInvalidQueryOptions)
+	}
+
+	// SKUs match as a prefix or exactly, with wildcards in the term taken literally
+	args := map[string]interface{}{"term": term, "sku": escapeLike(term), "prefix": escapeLike(term) + "%"}
+	query := filter.apply(r.db.Model(&models.Product{})).
+		Joins("LEFT JOIN categories ON categories.id = products.category_id").
+		Where(productSearchMatch, args)
+
+	page := &Page{Limit: opts.Limit, Offset: opts.Offset}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	query = query.Select(productSearchColumns, args).Order("rank DESC, products.id")
+	if opts.Offset > 0 {
+		query = query.Offset(opts.Offset)
+	}
+	if opts.Limit > 0 {
+		query = query.Limit(opts.Limit)
+	}
+
+	var rows []productSearchRow
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return []ProductSearchHit{}, page, nil
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	var products []models.Product
+	if err := r.withRelations().Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uint]models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	hits := make([]ProductSearchHit, 0, len(rows))
+	for _, row := range rows {
+		product, ok := byID[row.ID]
+		if !ok {
+			continue
+		}
+		hits = append(hits, ProductSearchHit{
+			Product:              product,
+			Rank:                 row.Rank,
+			NameHighlight:        row.NameHighlight,
+			DescriptionHighlight: row.DescriptionHighlight,
+			MatchedSKU:           row.MatchedSKU,
+		})
+	}
+	return hits, page, nil
+}
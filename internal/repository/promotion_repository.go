@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"gocats/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PromotionRepository interface {
+	Create(promotion *models.Promotion) error
+	FindAll() ([]models.Promotion, error)
+	FindByID(id uint) (*models.Promotion, error)
+	FindByIDs(ids []uint) ([]models.Promotion, error)
+	Update(promotion *models.Promotion) error
+	Delete(id uint) error
+	FindActive(at time.Time) ([]models.Promotion, error)
+}
+
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+func (r *promotionRepository) Create(promotion *models.Promotion) error {
+	return r.db.Create(promotion).Error
+}
+
+// FindAll returns promotions in evaluation order
+func (r *promotionRepository) FindAll() ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	err := r.db.Order("priority DESC, id").Find(&promotions).Error
+	return promotions, err
+}
+
+func (r *promotionRepository) FindByID(id uint) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := r.db.First(&promotion, id).Error; err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// FindByIDs returns promotions including deleted ones, for attribution of past sales
+func (r *promotionRepository) FindByIDs(ids []uint) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	if len(ids) == 0 {
+		return promotions, nil
+	}
+	err := r.db.Unscoped().Where("id IN ?", ids).Find(&promotions).Error
+	return promotions, err
+}
+
+func (r *promotionRepository) Update(promotion *models.Promotion) error {
+	return r.db.Save(promotion).Error
+}
+
+func (r *promotionRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Promotion{}, id).Error
+}
+
+// FindActive returns the promotions running at the given time in evaluation
+// order: highest priority first, then oldest first
+func (r *promotionRepository) FindActive(at time.Time) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	err := r.db.
+		Where("starts_at IS NULL OR starts_at <= ?", at).
+		Where("ends_at IS NULL OR ends_at > ?", at).
+		Order("priority DESC, id").
+		Find(&promotions).Error
+	return promotions, err
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// ErrInvalidQueryOptions is returned for unknown sort fields and malformed cursors
+var ErrInvalidQueryOptions = errors.New("invalid query options")
+
+// QueryOptions controls pagination and sorting of list queries. Limit 0 returns
+// all rows. When Cursor is set it takes precedence over Offset.
+type QueryOptions struct {
+	Limit  int
+	Offset int
+	Cursor string
+	Sort   string
+	Desc   bool
+}
+
+// Page describes the position of a result within the full result set
+type Page struct {
+	Total      int64
+	Limit      int
+	Offset     int
+	NextCursor string
+}
+
+// cursor is the keyset position after the last row of a page
+type cursor struct {
+	Value interface{} `json:"v"`
+	ID    uint        `json:"id"`
+}
+
+func encodeCursor(value interface{}, id uint) string {
+	b, _ := json.Marshal(cursor{Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidQueryOptions)
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidQueryOptions)
+	}
+	return &c, nil
+}
+
+// paginate counts the rows matched by query and applies sorting, the cursor or
+// offset and the limit. sortColumns maps the allowed sort names to columns of table.
+func paginate(query *gorm.DB, table string, opts QueryOptions, sortColumns map[string]string) (*gorm.DB, *Page, error) {
+	sort := opts.Sort
+	if sort == "" {
+		sort = "id"
+	}
+	column, ok := sortColumns[sort]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: invalid sort field %q", ErrInvalidQueryOptions, sort)
+	}
+
+	page := &Page{Limit: opts.Limit, Offset: opts.Offset}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	idColumn := table + ".id"
+
+	direction, comparison := "ASC", ">"
+	if opts.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", column, idColumn, comparison), c.Value, c.ID)
+		page.Offset = 0
+	} else if opts.Offset > 0 {
+		query = query.Offset(opts.Offset)
+	}
+
+	query = query.Order(fmt.Sprintf("%s %s, %s %s", column, direction, idColumn, direction))
+	if opts.Limit > 0 {
+		query = query.Limit(opts.Limit)
+	}
+
+	return query, page, nil
+}
+
+// setNextCursor fills the next cursor when the page is full, so clients can
+// keep fetching with keyset pagination
+func (p *Page) setNextCursor(rows int, lastValue interface{}, lastID uint) {
+	if p.Limit > 0 && rows == p.Limit {
+		p.NextCursor = encodeCursor(lastValue, lastID)
+	}
+}
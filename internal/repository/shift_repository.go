@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"gocats/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShiftRepository interface {
+	Create(tx *gorm.DB, shift *models.Shift) error
+	Update(tx *gorm.DB, shift *models.Shift) error
+	FindAll(cashier, status string, opts QueryOptions) ([]models.Shift, *Page, error)
+	FindByID(id uint) (*models.Shift, error)
+	FindOpen(cashier string) (*models.Shift, error)
+	LockByID(tx *gorm.DB, id uint) (*models.Shift, error)
+	LockOpen(tx *gorm.DB, cashier string) (*models.Shift, error)
+	CreateMovement(tx *gorm.DB, movement *models.CashMovement) error
+	Totals(tx *gorm.DB, shiftID uint) (*models.ShiftReport, error)
+}
+
+// shiftSortColumns are the sort fields accepted by shift list queries
+var shiftSortColumns = map[string]string{
+	"id":        "shifts.id",
+	"opened_at": "shifts.opened_at",
+}
+
+type shiftRepository struct {
+	db *gorm.DB
+}
+
+func NewShiftRepository(db *gorm.DB) ShiftRepository {
+	return &shiftRepository{db: db}
+}
+
+func (r *shiftRepository) Create(tx *gorm.DB, shift *models.Shift) error {
+	return tx.Create(shift).Error
+}
+
+func (r *shiftRepository) Update(tx *gorm.DB, shift *models.Shift) error {
+	return tx.Omit("Movements").Save(shift).Error
+}
+
+// FindAll returns a page of shifts, newest first by default, optionally of one
+// cashier or with one status
+func (r *shiftRepository) FindAll(cashier, status string, opts QueryOptions) ([]models.Shift, *Page, error) {
+	if opts.Sort == "" {
+		opts.Sort, opts.Desc = "opened_at", true
+	}
+
+	query := r.db.Model(&models.Shift{})
+	if cashier != "" {
+		query = query.Where("shifts.cashier = ?", cashier)
+	}
+	if status != "" {
+		query = query.Where("shifts.status = ?", status)
+	}
+
+	query, page, err := paginate(query, "shifts", opts, shiftSortColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var shifts []models.Shift
+	if err := query.Find(&shifts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if n := len(shifts); n > 0 {
+		last := shifts[n-1]
+		var value interface{} = last.ID
+		if opts.Sort == "opened_at" {
+			value = last.OpenedAt
+		}
+		page.setNextCursor(n, value, last.ID)
+	}
+
+	return shifts, page, nil
+}
+
+// FindByID returns a shift with its cash movements
+func (r *shiftRepository) FindByID(id uint) (*models.Shift, error) {
+	var shift models.Shift
+	err := r.db.Preload("Movements", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		First(&shift, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// FindOpen returns the open shift of a cashier
+func (r *shiftRepository) FindOpen(cashier string) (*models.Shift, error) {
+	var shift models.Shift
+	err := r.db.Where("cashier = ? AND status = ?", cashier, models.ShiftOpen).First(&shift).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// LockByID locks a shift row until tx ends
+func (r *shiftRepository) LockByID(tx *gorm.DB, id uint) (*models.Shift, error) {
+	var shift models.Shift
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, id).Error; err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// LockOpen share-locks the open shift of a cashier until tx ends, so it cannot
+// be closed while a checkout or refund is attributed to it
+func (r *shiftRepository) LockOpen(tx *gorm.DB, cashier string) (*models.Shift, error) {
+	var shift models.Shift
+	err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("cashier = ? AND status = ?", cashier, models.ShiftOpen).
+		First(&shift).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+func (r *shiftRepository) CreateMovement(tx *gorm.DB, movement *models.CashMovement) error {
+	return tx.Create(movement).Error
+}
+
+// Totals sums the transactions, payments, cash refunds and cash movements of a
+// shift. Shift, OpeningFloat and the cash figures derived from them are left
+// to the caller.
+func (r *shiftRepository) Totals(tx *gorm.DB, shiftID uint) (*models.ShiftReport, error) {
+	var report models.ShiftReport
+
+	var sales struct {
+		TransactionCount int64
+		SalesTotal       float64
+		CashSales        float64
+	}
+	err := tx.Model(&models.Transaction{}).
+		Select(`COUNT(id) AS transaction_count,
+			COALESCE(SUM(total_amount), 0) AS sales_total,
+			COALESCE(SUM(CASE WHEN payment_method = ? THEN amount_paid - change ELSE 0 END), 0) AS cash_sales`, models.PaymentCash).
+		Where("shift_id = ?", shiftID).
+		Scan(&sales).Error
+	if err != nil {
+		return nil, err
+	}
+	report.TransactionCount = sales.TransactionCount
+	report.SalesTotal = sales.SalesTotal
+	report.CashSales = sales.CashSales
+
+	// The balance due of every transaction, by the payment method that paid it
+	err = tx.Model(&models.Transaction{}).
+		Select("payment_method AS method, COUNT(id) AS count, COALESCE(SUM(amount_paid - change), 0) AS amount").
+		Where("shift_id = ?", shiftID).
+		Group("payment_method").
+		Order("payment_method").
+		Scan(&report.Payments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Model(&models.Refund{}).
+		Select("COALESCE(SUM(cash_amount), 0)").
+		Where("shift_id = ?", shiftID).
+		Scan(&report.CashRefunds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var movements struct {
+		PayIns  float64
+		PayOuts float64
+	}
+	err = tx.Model(&models.CashMovement{}).
+		Select(`COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE 0 END), 0) AS pay_ins,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE 0 END), 0) AS pay_outs`, models.CashPayIn, models.CashPayOut).
+		Where("shift_id = ?", shiftID).
+		Scan(&movements).Error
+	if err != nil {
+		return nil, err
+	}
+	report.PayIns = movements.PayIns
+	report.PayOuts = movements.PayOuts
+
+	return &report, nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"errors"
+	"gocats/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInsufficientBalance is returned when a ledger entry would take a stored
+// value account below zero
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+type StoredValueRepository interface {
+	CreateAccount(tx *gorm.DB, account *models.StoredValueAccount) error
+	FindByCode(code string) (*models.StoredValueAccount, error)
+	FindByCustomerID(customerID uint) (*models.StoredValueAccount, error)
+	LockByID(tx *gorm.DB, id uint) (*models.StoredValueAccount, error)
+	LockByCode(tx *gorm.DB, code string) (*models.StoredValueAccount, error)
+	LockStoreCredit(tx *gorm.DB, customerID uint) (*models.StoredValueAccount, error)
+	Post(tx *gorm.DB, account *models.StoredValueAccount, entry *models.StoredValueEntry) error
+	CreateTender(tx *gorm.DB, tender *models.TransactionTender) error
+	FindTendersByTransactionID(tx *gorm.DB, transactionID uint) ([]models.TransactionTender, error)
+}
+
+type storedValueRepository struct {
+	db *gorm.DB
+}
+
+func NewStoredValueRepository(db *gorm.DB) StoredValueRepository {
+	return &storedValueRepository{db: db}
+}
+
+// newestEntries orders a ledger newest first
+func newestEntries(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at DESC, id DESC")
+}
+
+func (r *storedValueRepository) CreateAccount(tx *gorm.DB, account *models.StoredValueAccount) error {
+	return tx.Create(account).Error
+}
+
+// FindByCode returns a gift card with its ledger
+func (r *storedValueRepository) FindByCode(code string) (*models.StoredValueAccount, error) {
+	var account models.StoredValueAccount
+	err := r.db.Preload("Entries", newestEntries).
+		Where("type = ? AND code = ?", models.AccountGiftCard, code).
+		First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// FindByCustomerID returns the store credit account of a customer with its ledger
+func (r *storedValueRepository) FindByCustomerID(customerID uint) (*models.StoredValueAccount, error) {
+	var account models.StoredValueAccount
+	err := r.db.Preload("Entries", newestEntries).
+		Where("type = ? AND customer_id = ?", models.AccountStoreCredit, customerID).
+		First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// LockByID locks an account row until tx ends
+func (r *storedValueRepository) LockByID(tx *gorm.DB, id uint) (*models.StoredValueAccount, error) {
+	var account models.StoredValueAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, id).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// LockByCode locks a gift card row until tx ends
+func (r *storedValueRepository) LockByCode(tx *gorm.DB, code string) (*models.StoredValueAccount, error) {
+	var account models.StoredValueAccount
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("type = ? AND code = ?", models.AccountGiftCard, code).
+		First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// LockStoreCredit locks the store credit account of a customer until tx ends,
+// opening it with a zero balance on first use
+func (r *storedValueRepository) LockStoreCredit(tx *gorm.DB, customerID uint) (*models.StoredValueAccount, error) {
+	opening := models.StoredValueAccount{Type: models.AccountStoreCredit, CustomerID: &customerID}
+	err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "customer_id"}}, DoNothing: true}).
+		Create(&opening).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var account models.StoredValueAccount
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("type = ? AND customer_id = ?", models.AccountStoreCredit, customerID).
+		First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Post applies the entry amount to the account balance and writes the entry to
+// the ledger. The balance is only changed when it stays at or above zero, so a
+// concurrent spend that got there first makes this one fail with
+// ErrInsufficientBalance.
+func (r *storedValueRepository) Post(tx *gorm.DB, account *models.StoredValueAccount, entry *models.StoredValueEntry) error {
+	result := tx.Model(account).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "balance"}}}).
+		Where("balance + ? >= 0", entry.Amount).
+		Update("balance", gorm.Expr("balance + ?", entry.Amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientBalance
+	}
+
+	entry.AccountID = account.ID
+	entry.BalanceAfter = account.Balance
+	return tx.Create(entry).Error
+}
+
+func (r *storedValueRepository) CreateTender(tx *gorm.DB, tender *models.TransactionTender) error {
+	return tx.Create(tender).Error
+}
+
+func (r *storedValueRepository) FindTendersByTransactionID(tx *gorm.DB, transactionID uint) ([]models.TransactionTender, error) {
+	var tenders []models.TransactionTender
+	err := tx.Where("transaction_id = ?", transactionID).Order("id").Find(&tenders).Error
+	return tenders, err
+}
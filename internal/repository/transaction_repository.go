@@ -1,18 +1,50 @@
 package repository
 
 import (
+	"errors"
 	"gocats/internal/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrInsufficientStock is returned when a stock update would take a product or
+// variant below zero
+var ErrInsufficientStock = errors.New("insufficient stock")
+
 type TransactionRepository interface {
 	CreateTransaction(tx *gorm.DB, transaction *models.Transaction) error
 	CreateTransactionDetail(tx *gorm.DB, detail *models.TransactionDetail) error
 	UpdateProductStock(tx *gorm.DB, productID uint, quantity int) error
+	UpdateVariantStock(tx *gorm.DB, variantID uint, quantity int) error
+	FindAll(filter TransactionFilter, opts QueryOptions) ([]models.Transaction, *Page, error)
 	FindByID(id uint) (*models.Transaction, error)
+	NextInvoiceSequence(tx *gorm.DB, series string) (int64, error)
+	FindForUpdate(tx *gorm.DB, id uint) (*models.Transaction, error)
+	CreateRefund(tx *gorm.DB, refund *models.Refund) error
 	GetTodaySummary() (*models.SalesSummary, error)
 	GetSummaryByDateRange(startDate, endDate string) (*models.SalesSummary, error)
+	GetBundleSales(startDate, endDate string) ([]models.BundleSales, error)
+	GetComponentSales(startDate, endDate string) ([]models.ComponentSales, error)
+	GetDepartmentSales(startDate, endDate string) ([]models.DepartmentSales, error)
+}
+
+// TransactionFilter combines the criteria of a transaction list query. Zero
+// values leave a criterion unset.
+type TransactionFilter struct {
+	// InvoiceNumber matches invoice numbers containing it, case-insensitively
+	InvoiceNumber string
+	CustomerID    *uint
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+}
+
+// transactionSortColumns are the sort fields accepted by transaction list queries
+var transactionSortColumns = map[string]string{
+	"id":           "transactions.id",
+	"created_at":   "transactions.created_at",
+	"total_amount": "transactions.total_amount",
 }
 
 type transactionRepository struct {
@@ -31,19 +63,127 @@ func (r *transactionRepository) CreateTransactionDetail(tx *gorm.DB, detail *mod
 	return tx.Create(detail).Error
 }
 
+// UpdateProductStock takes quantity from the stock of a product, a negative
+// quantity puts it back. Taking more than is in stock returns ErrInsufficientStock
+// and changes nothing, the check and update are one statement so concurrent
+// checkouts cannot oversell.
 func (r *transactionRepository) UpdateProductStock(tx *gorm.DB, productID uint, quantity int) error {
-	return tx.Model(&models.Product{}).Where("id = ?", productID).UpdateColumn("stock", gorm.Expr("stock - ?", quantity)).Error
+	return updateStock(tx.Model(&models.Product{}).Where("id = ?", productID), quantity)
+}
+
+// UpdateVariantStock takes quantity from the stock of a variant like UpdateProductStock
+func (r *transactionRepository) UpdateVariantStock(tx *gorm.DB, variantID uint, quantity int) error {
+	return updateStock(tx.Model(&models.ProductVariant{}).Where("id = ?", variantID), quantity)
+}
+
+func updateStock(query *gorm.DB, quantity int) error {
+	if quantity <= 0 {
+		return query.UpdateColumn("stock", gorm.Expr("stock - ?", quantity)).Error
+	}
+
+	result := query.Where("stock >= ?", quantity).UpdateColumn("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// FindAll returns a page of transactions matching the filter, newest first by default
+func (r *transactionRepository) FindAll(filter TransactionFilter, opts QueryOptions) ([]models.Transaction, *Page, error) {
+	if opts.Sort == "" {
+		opts.Sort, opts.Desc = "created_at", true
+	}
+
+	query := r.db.Model(&models.Transaction{})
+	if filter.InvoiceNumber != "" {
+		query = query.Where("transactions.invoice_number ILIKE ?", "%"+filter.InvoiceNumber+"%")
+	}
+	if filter.CustomerID != nil {
+		query = query.Where("transactions.customer_id = ?", *filter.CustomerID)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("transactions.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("transactions.created_at < ?", *filter.CreatedTo)
+	}
+
+	query, page, err := paginate(query, "transactions", opts, transactionSortColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var transactions []models.Transaction
+	err = query.Preload("TransactionDetails.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("TransactionDetails.Variant").
+		Preload("Refund").
+		Find(&transactions).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if n := len(transactions); n > 0 {
+		last := transactions[n-1]
+		var value interface{}
+		switch opts.Sort {
+		case "created_at":
+			value = last.CreatedAt
+		case "total_amount":
+			value = last.TotalAmount
+		default:
+			value = last.ID
+		}
+		page.setNextCursor(n, value, last.ID)
+	}
+
+	return transactions, page, nil
 }
 
 func (r *transactionRepository) FindByID(id uint) (*models.Transaction, error) {
 	var transaction models.Transaction
-	err := r.db.Preload("TransactionDetails.Product").First(&transaction, id).Error
+	err := r.db.Preload("TransactionDetails.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Preload("TransactionDetails.Variant").Preload("TransactionDetails.BundleComponents").Preload("Customer", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Preload("Refund").Preload("Tenders.Account").First(&transaction, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// FindForUpdate locks a transaction row until tx ends and loads its lines and refund
+func (r *transactionRepository) FindForUpdate(tx *gorm.DB, id uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("TransactionDetails.BundleComponents").
+		Preload("Refund").
+		Preload("Tenders").
+		First(&transaction, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &transaction, nil
 }
 
+// NextInvoiceSequence increments the sequence of an invoice series and returns
+// it. The sequence row stays locked until tx ends, so concurrent checkouts take
+// their numbers in turn and a rolled back checkout leaves no gap.
+func (r *transactionRepository) NextInvoiceSequence(tx *gorm.DB, series string) (int64, error) {
+	var sequence int64
+	err := tx.Raw(`INSERT INTO invoice_sequences (series, value, updated_at) VALUES (?, 1, NOW())
+		ON CONFLICT (series) DO UPDATE SET value = invoice_sequences.value + 1, updated_at = NOW()
+		RETURNING value`, series).Scan(&sequence).Error
+	return sequence, err
+}
+
+func (r *transactionRepository) CreateRefund(tx *gorm.DB, refund *models.Refund) error {
+	return tx.Create(refund).Error
+}
+
+// notRefunded leaves out refunded transactions. Refunds return the whole
+// transaction, so sales reports count only the sales that were kept.
+const notRefunded = "NOT EXISTS (SELECT 1 FROM refunds WHERE refunds.transaction_id = transactions.id)"
+
 func (r *transactionRepository) GetTodaySummary() (*models.SalesSummary, error) {
 	var summary models.SalesSummary
 
@@ -57,6 +197,7 @@ func (r *transactionRepository) GetTodaySummary() (*models.SalesSummary, error)
 	err := r.db.Model(&models.Transaction{}).
 		Select("COALESCE(SUM(total_amount), 0) as total_revenue, COUNT(id) as total_transactions").
 		Where("DATE(created_at) = CURRENT_DATE").
+		Where(notRefunded).
 		Scan(&result).Error
 
 	if err != nil {
@@ -79,6 +220,7 @@ func (r *transactionRepository) GetTodaySummary() (*models.SalesSummary, error)
 		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
 		Joins("JOIN products ON products.id = transaction_details.product_id").
 		Where("DATE(transactions.created_at) = CURRENT_DATE").
+		Where(notRefunded).
 		Group("transaction_details.product_id, products.name").
 		Order("qty_sold DESC").
 		Limit(1).
@@ -112,6 +254,7 @@ func (r *transactionRepository) GetSummaryByDateRange(startDate, endDate string)
 	err := r.db.Model(&models.Transaction{}).
 		Select("COALESCE(SUM(total_amount), 0) as total_revenue, COUNT(id) as total_transactions").
 		Where("DATE(created_at) >= ? AND DATE(created_at) <= ?", startDate, endDate).
+		Where(notRefunded).
 		Scan(&result).Error
 
 	if err != nil {
@@ -134,6 +277,7 @@ func (r *transactionRepository) GetSummaryByDateRange(startDate, endDate string)
 		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
 		Joins("JOIN products ON products.id = transaction_details.product_id").
 		Where("DATE(transactions.created_at) >= ? AND DATE(transactions.created_at) <= ?", startDate, endDate).
+		Where(notRefunded).
 		Group("transaction_details.product_id, products.name").
 		Order("qty_sold DESC").
 		Limit(1).
@@ -153,3 +297,80 @@ func (r *transactionRepository) GetSummaryByDateRange(startDate, endDate string)
 
 	return &summary, nil
 }
+
+// GetBundleSales returns units sold and revenue per bundle product in the date range
+func (r *transactionRepository) GetBundleSales(startDate, endDate string) ([]models.BundleSales, error) {
+	var sales []models.BundleSales
+	err := r.db.Model(&models.TransactionDetail{}).
+		Select("transaction_details.product_id, products.name, SUM(transaction_details.quantity) as qty_sold, SUM(transaction_details.subtotal) as revenue").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
+		Joins("JOIN products ON products.id = transaction_details.product_id").
+		Where("DATE(transactions.created_at) >= ? AND DATE(transactions.created_at) <= ?", startDate, endDate).
+		Where(notRefunded).
+		Where("EXISTS (SELECT 1 FROM transaction_bundle_components tbc WHERE tbc.transaction_detail_id = transaction_details.id)").
+		Group("transaction_details.product_id, products.name").
+		Order("qty_sold DESC").
+		Scan(&sales).Error
+	return sales, err
+}
+
+// GetComponentSales returns units sold per product in the date range, split into
+// direct sales and units consumed by bundles
+func (r *transactionRepository) GetComponentSales(startDate, endDate string) ([]models.ComponentSales, error) {
+	var sales []models.ComponentSales
+	err := r.db.Raw(`
+		SELECT u.product_id, p.name,
+			SUM(u.direct_qty) AS direct_qty,
+			SUM(u.bundle_qty) AS bundle_qty,
+			SUM(u.direct_qty + u.bundle_qty) AS total_qty
+		FROM (
+			SELECT td.product_id, td.quantity AS direct_qty, 0 AS bundle_qty
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			WHERE DATE(t.created_at) >= ? AND DATE(t.created_at) <= ?
+				AND NOT EXISTS (SELECT 1 FROM refunds WHERE refunds.transaction_id = t.id)
+				AND NOT EXISTS (SELECT 1 FROM transaction_bundle_components tbc WHERE tbc.transaction_detail_id = td.id)
+			UNION ALL
+			SELECT tbc.product_id, 0 AS direct_qty, tbc.quantity AS bundle_qty
+			FROM transaction_bundle_components tbc
+			JOIN transaction_details td ON td.id = tbc.transaction_detail_id
+			JOIN transactions t ON t.id = td.transaction_id
+			WHERE DATE(t.created_at) >= ? AND DATE(t.created_at) <= ?
+				AND NOT EXISTS (SELECT 1 FROM refunds WHERE refunds.transaction_id = t.id)
+		) u
+		JOIN products p ON p.id = u.product_id
+		GROUP BY u.product_id, p.name
+		ORDER BY total_qty DESC`,
+		startDate, endDate, startDate, endDate).
+		Scan(&sales).Error
+	return sales, err
+}
+
+// GetDepartmentSales rolls up units sold and revenue to the top-level category
+// (department) of each sold product. Archived categories are included so past
+// sales keep their department.
+func (r *transactionRepository) GetDepartmentSales(startDate, endDate string) ([]models.DepartmentSales, error) {
+	var sales []models.DepartmentSales
+	err := r.db.Raw(`
+		WITH RECURSIVE roots AS (
+			SELECT id, id AS root_id FROM categories WHERE parent_id IS NULL
+			UNION ALL
+			SELECT c.id, r.root_id FROM categories c
+			JOIN roots r ON c.parent_id = r.id
+		)
+		SELECT roots.root_id AS category_id, dept.name,
+			SUM(td.quantity) AS qty_sold,
+			SUM(td.subtotal) AS revenue
+		FROM transaction_details td
+		JOIN transactions t ON t.id = td.transaction_id
+		JOIN products p ON p.id = td.product_id
+		JOIN roots ON roots.id = p.category_id
+		JOIN categories dept ON dept.id = roots.root_id
+		WHERE DATE(t.created_at) >= ? AND DATE(t.created_at) <= ?
+			AND NOT EXISTS (SELECT 1 FROM refunds WHERE refunds.transaction_id = t.id)
+		GROUP BY roots.root_id, dept.name
+		ORDER BY revenue DESC`,
+		startDate, endDate).
+		Scan(&sales).Error
+	return sales, err
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+// excludesRefunds matches a report query that leaves out refunded transactions
+var excludesRefunds = regexp.QuoteMeta("NOT EXISTS (SELECT 1 FROM refunds WHERE refunds.transaction_id = ")
+
+func TestSummaryLeavesOutRefundedTransactions(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+
+	// The mock answers for the kept sale only, the queries must ask for that
+	mock.ExpectQuery(`SUM\(total_amount\).*FROM "transactions".*`+excludesRefunds+`transactions\.id\)`).
+		WithArgs("2026-03-01", "2026-03-01").
+		WillReturnRows(sqlmock.NewRows([]string{"total_revenue", "total_transactions"}).AddRow(30, 1))
+	mock.ExpectQuery(`SUM\(transaction_details\.quantity\).*`+excludesRefunds+`transactions\.id\)`).
+		WithArgs("2026-03-01", "2026-03-01", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "name", "qty_sold"}).AddRow(7, "Coffee", 3))
+
+	summary, err := repo.GetSummaryByDateRange("2026-03-01", "2026-03-01")
+	if err != nil {
+		t.Fatalf("GetSummaryByDateRange: %v", err)
+	}
+	if summary.TotalRevenue != 30 || summary.TotalTransactions != 1 {
+		t.Errorf("summary = %+v, want revenue 30 from 1 transaction", summary)
+	}
+	if summary.BestSellingProduct == nil || summary.BestSellingProduct.QtySold != 3 {
+		t.Errorf("best selling product = %+v", summary.BestSellingProduct)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSalesReportsLeaveOutRefundedTransactions(t *testing.T) {
+	for name, tc := range map[string]struct {
+		run     func(TransactionRepository) error
+		columns []string
+		alias   string
+		count   int
+	}{
+		"bundles": {
+			run: func(r TransactionRepository) error {
+				_, err := r.GetBundleSales("2026-03-01", "2026-03-31")
+				return err
+			},
+			columns: []string{"product_id", "name", "qty_sold", "revenue"},
+			alias:   "transactions",
+			count:   1,
+		},
+		"components": {
+			run: func(r TransactionRepository) error {
+				_, err := r.GetComponentSales("2026-03-01", "2026-03-31")
+				return err
+			},
+			columns: []string{"product_id", "name", "direct_qty", "bundle_qty", "total_qty"},
+			alias:   "t",
+			count:   2,
+		},
+		"departments": {
+			run: func(r TransactionRepository) error {
+				_, err := r.GetDepartmentSales("2026-03-01", "2026-03-31")
+				return err
+			},
+			columns: []string{"category_id", "name", "qty_sold", "revenue"},
+			alias:   "t",
+			count:   1,
+		},
+	} {
+		db, mock := newMockDB(t)
+		// Every part of the query that reads transactions must skip refunds
+		pattern := strings.Repeat(excludesRefunds+regexp.QuoteMeta(tc.alias+".id)")+`(?s:.*)`, tc.count)
+		mock.ExpectQuery(pattern).WillReturnRows(sqlmock.NewRows(tc.columns))
+
+		if err := tc.run(NewTransactionRepository(db)); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
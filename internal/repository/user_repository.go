@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"errors"
+	"gocats/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLastActiveAdmin is returned when a change would leave no active admin
+var ErrLastActiveAdmin = errors.New("cannot remove the last active admin")
+
+type UserRepository interface {
+	Create(user *models.User) error
+	FindAll(role string, opts QueryOptions) ([]models.User, *Page, error)
+	FindByID(id uint) (*models.User, error)
+	FindByUsername(username string) (*models.User, error)
+	Update(user *models.User) error
+	UpdateKeepingAdmin(user *models.User) error
+	Delete(id uint) error
+	DeleteKeepingAdmin(id uint) error
+	Count() (int64, error)
+	TouchLogin(id uint, at time.Time) error
+}
+
+// userSortColumns are the sort fields accepted by user list queries
+var userSortColumns = map[string]string{
+	"id":       "users.id",
+	"username": "users.username",
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+// FindAll returns a page of users, optionally only those with one role
+func (r *userRepository) FindAll(role string, opts QueryOptions) ([]models.User, *Page, error) {
+	query := r.db.Model(&models.User{})
+	if role != "" {
+		query = query.Where("users.role = ?", role)
+	}
+
+	query, page, err := paginate(query, "users", opts, userSortColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var users []models.User
+	if err := query.Find(&users).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if n := len(users); n > 0 {
+		last := users[n-1]
+		var value interface{} = last.ID
+		if opts.Sort == "username" {
+			value = last.Username
+		}
+		page.setNextCursor(n, value, last.ID)
+	}
+
+	return users, page, nil
+}
+
+func (r *userRepository) FindByID(id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByUsername returns the user with username, including deleted users so
+// their username is not given to someone else
+func (r *userRepository) FindByUsername(username string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Unscoped().Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Update(user *models.User) error {
+	return r.db.Save(user).Error
+}
+
+// Delete archives the user, their name stays on the changes they made
+func (r *userRepository) Delete(id uint) error {
+	return r.db.Delete(&models.User{}, id).Error
+}
+
+// UpdateKeepingAdmin saves a user that may no longer be an active admin,
+// unless it is the last one
+func (r *userRepository) UpdateKeepingAdmin(user *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := keepAdmin(tx, user.ID); err != nil {
+			return err
+		}
+		return tx.Save(user).Error
+	})
+}
+
+// DeleteKeepingAdmin archives the user unless it is the last active admin
+func (r *userRepository) DeleteKeepingAdmin(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := keepAdmin(tx, id); err != nil {
+			return err
+		}
+		return tx.Delete(&models.User{}, id).Error
+	})
+}
+
+// keepAdmin returns ErrLastActiveAdmin when id is the only active admin. The
+// active admins stay locked until tx ends, so concurrent changes cannot remove
+// the last two admins at once.
+func keepAdmin(tx *gorm.DB, id uint) error {
+	var admins []uint
+	err := tx.Model(&models.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ? AND active", models.RoleAdmin).
+		Pluck("id", &admins).Error
+	if err != nil {
+		return err
+	}
+	if len(admins) == 1 && admins[0] == id {
+		return ErrLastActiveAdmin
+	}
+	return nil
+}
+
+// Count returns the number of users, including deleted ones
+func (r *userRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.User{}).Count(&count).Error
+	return count, err
+}
+
+// TouchLogin records the time of the last successful sign in of a user
+func (r *userRepository) TouchLogin(id uint, at time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("last_login_at", at).Error
+}
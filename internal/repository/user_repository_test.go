@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// lockAdmins matches the query locking the active admins
+var lockAdmins = regexp.QuoteMeta(`SELECT "id" FROM "users" WHERE (role = $1 AND active) AND "users"."deleted_at" IS NULL FOR UPDATE`)
+
+func TestDeleteKeepingAdminRefusesLastAdmin(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockAdmins).WithArgs("admin").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectRollback()
+
+	if err := repo.DeleteKeepingAdmin(1); !errors.Is(err, ErrLastActiveAdmin) {
+		t.Errorf("DeleteKeepingAdmin(last admin) = %v, want ErrLastActiveAdmin", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteKeepingAdminWithAnotherAdmin(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockAdmins).WithArgs("admin").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.DeleteKeepingAdmin(1); err != nil {
+		t.Errorf("DeleteKeepingAdmin: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"gocats/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type VariantRepository interface {
+	SaveOption(option *models.ProductOption) error
+	FindOptionsByProductID(productID uint) ([]models.ProductOption, error)
+	Create(variant *models.ProductVariant) error
+	FindByID(id uint) (*models.ProductVariant, error)
+	FindByProductID(productID uint) ([]models.ProductVariant, error)
+	Update(variant *models.ProductVariant) error
+	Delete(id uint) error
+}
+
+type variantRepository struct {
+	db *gorm.DB
+}
+
+func NewVariantRepository(db *gorm.DB) VariantRepository {
+	return &variantRepository{db: db}
+}
+
+// SaveOption creates the option or replaces the values of an existing option with the same name
+func (r *variantRepository) SaveOption(option *models.ProductOption) error {
+	var existing models.ProductOption
+	err := r.db.Where("product_id = ? AND name = ?", option.ProductID, option.Name).First(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		return r.db.Create(option).Error
+	}
+	if err != nil {
+		return err
+	}
+	option.ID = existing.ID
+	return r.db.Save(option).Error
+}
+
+func (r *variantRepository) FindOptionsByProductID(productID uint) ([]models.ProductOption, error) {
+	var options []models.ProductOption
+	err := r.db.Where("product_id = ?", productID).Order("id").Find(&options).Error
+	return options, err
+}
+
+func (r *variantRepository) Create(variant *models.ProductVariant) error {
+	return r.db.Create(variant).Error
+}
+
+func (r *variantRepository) FindByID(id uint) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	err := r.db.First(&variant, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (r *variantRepository) FindByProductID(productID uint) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	err := r.db.Where("product_id = ?", productID).Order("id").Find(&variants).Error
+	return variants, err
+}
+
+func (r *variantRepository) Update(variant *models.ProductVariant) error {
+	return r.db.Save(variant).Error
+}
+
+func (r *variantRepository) Delete(id uint) error {
+	return r.db.Delete(&models.ProductVariant{}, id).Error
+}
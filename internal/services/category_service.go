@@ -2,36 +2,86 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"gocats/internal/models"
 	"gocats/internal/repository"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
 type CategoryService interface {
-	CreateCategory(name, description string) (*models.Category, error)
-	GetAllCategories() ([]models.Category, error)
+	CreateCategory(name, description string, parentID *uint) (*models.Category, error)
+	GetAllCategories(opts repository.QueryOptions) ([]models.Category, *repository.Page, error)
 	GetCategoryByID(id uint) (*models.Category, error)
-	UpdateCategory(id uint, name, description string) (*models.Category, error)
-	DeleteCategory(id uint) error
+	UpdateCategory(id uint, name, description string, parentID *uint) (*models.Category, error)
+	DeleteCategory(id uint, opts DeleteCategoryOptions) (*DeleteCategoryResult, error)
+	SetAttributeSchema(id uint, schema models.AttributeSchema) (*models.Category, error)
+	GetArchivedCategories() ([]models.Category, error)
+	RestoreCategory(id uint) (*models.Category, error)
+	GetCategoryTree() ([]models.CategoryNode, error)
+	GetCategorySubtree(id uint) (*models.CategoryNode, error)
+	MergeCategories(targetID uint, sourceIDs []uint, deleteSources bool) (*models.CategoryMerge, error)
+	GetCategoryMerges(targetID uint) ([]models.CategoryMerge, error)
+}
+
+// DeleteCategoryOptions decides what happens to products of a deleted category.
+// Without options deletion is refused while the category has active products.
+type DeleteCategoryOptions struct {
+	ReassignTo      uint
+	ArchiveProducts bool
+}
+
+type DeleteCategoryResult struct {
+	ProductsReassigned int64 `json:"products_reassigned,omitempty"`
+	ProductsArchived   int64 `json:"products_archived,omitempty"`
+}
+
+// CategoryInUseError is returned when a category still has products and no
+// reassignment or cascade was requested
+type CategoryInUseError struct {
+	CategoryID       uint
+	ActiveProducts   int64
+	ArchivedProducts int64
+}
+
+func (e *CategoryInUseError) Error() string {
+	return fmt.Sprintf("category %d still has %d active and %d archived products, reassign them to another category or archive them",
+		e.CategoryID, e.ActiveProducts, e.ArchivedProducts)
 }
 
 type categoryService struct {
+	db   *gorm.DB
 	repo repository.CategoryRepository
 }
 
-func NewCategoryService(repo repository.CategoryRepository) CategoryService {
-	return &categoryService{repo: repo}
+func NewCategoryService(db *gorm.DB, repo repository.CategoryRepository) CategoryService {
+	return &categoryService{db: db, repo: repo}
 }
 
-func (s *categoryService) CreateCategory(name, description string) (*models.Category, error) {
+func (s *categoryService) CreateCategory(name, description string, parentID *uint) (*models.Category, error) {
 	if name == "" {
 		return nil, errors.New("category name is required")
 	}
 
+	// The unique name index also covers archived categories
+	if archived, err := s.repo.FindArchivedByName(name); err == nil {
+		return nil, fmt.Errorf("category %s is archived, restore category ID %d instead", name, archived.ID)
+	}
+
+	if parentID != nil && *parentID == 0 {
+		parentID = nil
+	}
+	if parentID != nil {
+		if err := s.validateParent(0, *parentID); err != nil {
+			return nil, err
+		}
+	}
+
 	category := &models.Category{
 		Name:        name,
 		Description: description,
+		ParentID:    parentID,
 	}
 
 	if err := s.repo.Create(category); err != nil {
@@ -41,8 +91,8 @@ func (s *categoryService) CreateCategory(name, description string) (*models.Cate
 	return category, nil
 }
 
-func (s *categoryService) GetAllCategories() ([]models.Category, error) {
-	return s.repo.FindAll()
+func (s *categoryService) GetAllCategories(opts repository.QueryOptions) ([]models.Category, *repository.Page, error) {
+	return s.repo.FindAll(opts)
 }
 
 func (s *categoryService) GetCategoryByID(id uint) (*models.Category, error) {
@@ -56,7 +106,9 @@ func (s *categoryService) GetCategoryByID(id uint) (*models.Category, error) {
 	return category, nil
 }
 
-func (s *categoryService) UpdateCategory(id uint, name, description string) (*models.Category, error) {
+// UpdateCategory changes the given fields. A nil parentID keeps the current
+// parent, a parentID of 0 moves the category to the top level.
+func (s *categoryService) UpdateCategory(id uint, name, description string, parentID *uint) (*models.Category, error) {
 	category, err := s.repo.FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -72,6 +124,17 @@ func (s *categoryService) UpdateCategory(id uint, name, description string) (*mo
 		category.Description = description
 	}
 
+	if parentID != nil {
+		if *parentID == 0 {
+			category.ParentID = nil
+		} else {
+			if err := s.validateParent(id, *parentID); err != nil {
+				return nil, err
+			}
+			category.ParentID = parentID
+		}
+	}
+
 	if err := s.repo.Update(category); err != nil {
 		return nil, err
 	}
@@ -79,14 +142,357 @@ func (s *categoryService) UpdateCategory(id uint, name, description string) (*mo
 	return category, nil
 }
 
-func (s *categoryService) DeleteCategory(id uint) error {
+func (s *categoryService) DeleteCategory(id uint, opts DeleteCategoryOptions) (*DeleteCategoryResult, error) {
 	_, err := s.repo.FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("category not found")
+			return nil, errors.New("category not found")
+		}
+		return nil, err
+	}
+
+	if opts.ReassignTo != 0 && opts.ArchiveProducts {
+		return nil, errors.New("choose either reassigning or archiving the products, not both")
+	}
+
+	// Subcategories of an archived parent would drop out of the tree, whatever
+	// happens to the products
+	children, err := s.repo.CountChildren(id)
+	if err != nil {
+		return nil, err
+	}
+	if children > 0 {
+		return nil, fmt.Errorf("category has %d subcategories, move or delete them first", children)
+	}
+
+	if opts.ReassignTo != 0 {
+		if opts.ReassignTo == id {
+			return nil, errors.New("cannot reassign products to the category being deleted")
+		}
+		if _, err := s.repo.FindByID(opts.ReassignTo); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("target category not found")
+			}
+			return nil, err
+		}
+
+		moved, err := s.repo.ReassignAndDelete(id, opts.ReassignTo)
+		if err != nil {
+			return nil, err
+		}
+		return &DeleteCategoryResult{ProductsReassigned: moved}, nil
+	}
+
+	if opts.ArchiveProducts {
+		archived, err := s.repo.DeleteWithProducts(id)
+		if err != nil {
+			return nil, err
+		}
+		return &DeleteCategoryResult{ProductsArchived: archived}, nil
+	}
+
+	active, archived, err := s.repo.CountProducts(id)
+	if err != nil {
+		return nil, err
+	}
+	if active > 0 {
+		return nil, &CategoryInUseError{CategoryID: id, ActiveProducts: active, ArchivedProducts: archived}
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return nil, err
+	}
+	return &DeleteCategoryResult{}, nil
+}
+
+// SetAttributeSchema replaces the custom attribute definitions that products in
+// the category are validated against
+func (s *categoryService) SetAttributeSchema(id uint, schema models.AttributeSchema) (*models.Category, error) {
+	category, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("category not found")
+		}
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, def := range schema {
+		if def.Name == "" {
+			return nil, errors.New("attribute name is required")
+		}
+		if seen[def.Name] {
+			return nil, fmt.Errorf("attribute %s is defined more than once", def.Name)
+		}
+		seen[def.Name] = true
+
+		switch def.Type {
+		case models.AttributeTypeString:
+		case models.AttributeTypeNumber, models.AttributeTypeBoolean:
+			if len(def.Allowed) > 0 {
+				return nil, fmt.Errorf("allowed values are only supported for string attribute %s", def.Name)
+			}
+		default:
+			return nil, fmt.Errorf("invalid type %q for attribute %s", def.Type, def.Name)
+		}
+	}
+
+	category.AttributeSchema = schema
+	if err := s.repo.Update(category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *categoryService) GetArchivedCategories() ([]models.Category, error) {
+	return s.repo.FindArchived()
+}
+
+// RestoreCategory brings an archived category back into listings
+func (s *categoryService) RestoreCategory(id uint) (*models.Category, error) {
+	if _, err := s.repo.FindArchivedByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("archived category not found")
+		}
+		return nil, err
+	}
+
+	if err := s.repo.Restore(id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindByID(id)
+}
+
+func (s *categoryService) GetCategoryTree() ([]models.CategoryNode, error) {
+	categories, _, err := s.repo.FindAll(repository.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCategoryTree(categories, nil), nil
+}
+
+func (s *categoryService) GetCategorySubtree(id uint) (*models.CategoryNode, error) {
+	category, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("category not found")
+		}
+		return nil, err
+	}
+
+	categories, _, err := s.repo.FindAll(repository.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CategoryNode{
+		ID:          category.ID,
+		Name:        category.Name,
+		Description: category.Description,
+		ParentID:    category.ParentID,
+		Children:    buildCategoryTree(categories, &category.ID),
+	}, nil
+}
+
+// validateParent checks that parentID exists and that making it the parent of
+// category id does not create a cycle. id is 0 for new categories.
+func (s *categoryService) validateParent(id, parentID uint) error {
+	if parentID == id {
+		return errors.New("a category cannot be its own parent")
+	}
+
+	if _, err := s.repo.FindByID(parentID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("parent category not found")
 		}
 		return err
 	}
 
-	return s.repo.Delete(id)
+	if id == 0 {
+		return nil
+	}
+
+	descendants, err := s.repo.FindDescendantIDs(id)
+	if err != nil {
+		return err
+	}
+	for _, descendant := range descendants {
+		if descendant == parentID {
+			return errors.New("parent category cannot be a subcategory of the category")
+		}
+	}
+
+	return nil
+}
+
+// buildCategoryTree returns the nested children of parentID (top-level
+// categories when parentID is nil)
+func buildCategoryTree(categories []models.Category, parentID *uint) []models.CategoryNode {
+	byParent := make(map[uint][]models.Category)
+	var roots []models.Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			byParent[*category.ParentID] = append(byParent[*category.ParentID], category)
+		}
+	}
+
+	var build func(level []models.Category) []models.CategoryNode
+	build = func(level []models.Category) []models.CategoryNode {
+		nodes := make([]models.CategoryNode, len(level))
+		for i, category := range level {
+			nodes[i] = models.CategoryNode{
+				ID:          category.ID,
+				Name:        category.Name,
+				Description: category.Description,
+				ParentID:    category.ParentID,
+				Children:    build(byParent[category.ID]),
+			}
+		}
+		return nodes
+	}
+
+	if parentID == nil {
+		return build(roots)
+	}
+	return build(byParent[*parentID])
+}
+
+// categoryBreadcrumbs returns the path from the top-level category down to
+// categoryID, using a lookup of all categories by ID
+func categoryBreadcrumbs(categoryID uint, byID map[uint]models.Category) []models.CategoryRef {
+	var path []models.CategoryRef
+	seen := make(map[uint]bool)
+	for id := categoryID; id != 0 && !seen[id]; {
+		seen[id] = true
+		category, ok := byID[id]
+		if !ok {
+			break
+		}
+		path = append([]models.CategoryRef{{ID: category.ID, Name: category.Name}}, path...)
+		if category.ParentID == nil {
+			break
+		}
+		id = *category.ParentID
+	}
+	return path
+}
+
+// MergeCategories moves all products and subcategories of the source categories
+// into the target, appends the source descriptions to the target description,
+// archives (or permanently deletes) the sources and records the merge, all in
+// one DB transaction
+func (s *categoryService) MergeCategories(targetID uint, sourceIDs []uint, deleteSources bool) (*models.CategoryMerge, error) {
+	if len(sourceIDs) == 0 {
+		return nil, errors.New("source_ids cannot be empty")
+	}
+
+	target, err := s.repo.FindByID(targetID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("target category not found")
+		}
+		return nil, err
+	}
+
+	sources := make([]*models.Category, 0, len(sourceIDs))
+	seen := make(map[uint]bool)
+	for _, id := range sourceIDs {
+		if id == targetID {
+			return nil, errors.New("a category cannot be merged into itself")
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("source category ID %d is listed more than once", id)
+		}
+		seen[id] = true
+
+		source, err := s.repo.FindByID(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("source category ID %d not found", id)
+			}
+			return nil, err
+		}
+
+		// Moving the children of a source under the target would create a cycle
+		// when the target itself is below that source
+		descendants, err := s.repo.FindDescendantIDs(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, descendant := range descendants {
+			if descendant == targetID {
+				return nil, fmt.Errorf("target category is a subcategory of source %s", source.Name)
+			}
+		}
+
+		sources = append(sources, source)
+	}
+
+	description := target.Description
+	names := make([]string, len(sources))
+	for i, source := range sources {
+		names[i] = source.Name
+		if source.Description != "" && !strings.Contains(description, source.Description) {
+			if description != "" {
+				description += "\n"
+			}
+			description += source.Description
+		}
+	}
+
+	merge := &models.CategoryMerge{
+		TargetID:      targetID,
+		SourceIDs:     models.UintArray(sourceIDs),
+		SourceNames:   models.StringArray(names),
+		SourcesPurged: deleteSources,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		moved, err := s.repo.MoveProducts(tx, sourceIDs, targetID)
+		if err != nil {
+			return fmt.Errorf("failed to move products: %w", err)
+		}
+		merge.ProductsMoved = moved
+
+		if err := s.repo.ReparentChildren(tx, sourceIDs, targetID); err != nil {
+			return fmt.Errorf("failed to move subcategories: %w", err)
+		}
+
+		if description != target.Description {
+			if err := s.repo.UpdateDescription(tx, targetID, description); err != nil {
+				return fmt.Errorf("failed to merge descriptions: %w", err)
+			}
+		}
+
+		if err := s.repo.DeleteMany(tx, sourceIDs, deleteSources); err != nil {
+			return fmt.Errorf("failed to remove source categories: %w", err)
+		}
+
+		if err := s.repo.CreateMerge(tx, merge); err != nil {
+			return fmt.Errorf("failed to record merge: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merge, nil
+}
+
+func (s *categoryService) GetCategoryMerges(targetID uint) ([]models.CategoryMerge, error) {
+	if _, err := s.repo.FindByID(targetID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("category not found")
+		}
+		return nil, err
+	}
+
+	return s.repo.FindMerges(targetID)
 }
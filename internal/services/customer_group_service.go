@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gocats/internal/models"
+	"gocats/internal/repository"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type CustomerGroupService interface {
+	CreateCustomerGroup(name, description string, tiers []models.PriceTier) (*models.CustomerGroup, error)
+	GetCustomerGroups() ([]models.CustomerGroup, error)
+	GetCustomerGroupByID(id uint) (*models.CustomerGroup, error)
+	UpdateCustomerGroup(id uint, name, description string, tiers []models.PriceTier) (*models.CustomerGroup, error)
+	DeleteCustomerGroup(id uint) error
+}
+
+type customerGroupService struct {
+	groupRepo   repository.CustomerGroupRepository
+	productRepo repository.ProductRepository
+}
+
+func NewCustomerGroupService(groupRepo repository.CustomerGroupRepository, productRepo repository.ProductRepository) CustomerGroupService {
+	return &customerGroupService{
+		groupRepo:   groupRepo,
+		productRepo: productRepo,
+	}
+}
+
+func (s *customerGroupService) CreateCustomerGroup(name, description string, tiers []models.PriceTier) (*models.CustomerGroup, error) {
+	name = strings.TrimSpace(name)
+	if err := s.validate(name, tiers); err != nil {
+		return nil, err
+	}
+
+	for i := range tiers {
+		tiers[i].ID = 0
+	}
+
+	group := &models.CustomerGroup{
+		Name:        name,
+		Description: description,
+		Tiers:       tiers,
+	}
+	if err := s.groupRepo.Create(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *customerGroupService) GetCustomerGroups() ([]models.CustomerGroup, error) {
+	return s.groupRepo.FindAll()
+}
+
+func (s *customerGroupService) GetCustomerGroupByID(id uint) (*models.CustomerGroup, error) {
+	if id == 0 {
+		return nil, errors.New("customer group ID cannot be zero")
+	}
+
+	group, err := s.groupRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("customer group not found")
+		}
+		return nil, err
+	}
+	return group, nil
+}
+
+// UpdateCustomerGroup renames a group and replaces its price tiers
+func (s *customerGroupService) UpdateCustomerGroup(id uint, name, description string, tiers []models.PriceTier) (*models.CustomerGroup, error) {
+	group, err := s.GetCustomerGroupByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if err := s.validate(name, tiers); err != nil {
+		return nil, err
+	}
+
+	group.Name = name
+	group.Description = description
+	group.Tiers = tiers
+	if err := s.groupRepo.Update(group); err != nil {
+		return nil, err
+	}
+	return s.GetCustomerGroupByID(id)
+}
+
+// DeleteCustomerGroup removes a group, its customers keep their purchases but
+// pay regular prices from then on
+func (s *customerGroupService) DeleteCustomerGroup(id uint) error {
+	if _, err := s.GetCustomerGroupByID(id); err != nil {
+		return err
+	}
+	return s.groupRepo.Delete(id)
+}
+
+// validate checks that every tier targets an existing product with either a
+// fixed price or a discount, and that a product has one tier per minimum quantity
+func (s *customerGroupService) validate(name string, tiers []models.PriceTier) error {
+	if name == "" {
+		return errors.New("customer group name cannot be empty")
+	}
+
+	seen := make(map[[2]uint]bool, len(tiers))
+	for i, tier := range tiers {
+		if tier.ProductID == 0 {
+			return fmt.Errorf("tier %d must have a product_id", i+1)
+		}
+		if tier.MinQuantity < 1 {
+			return fmt.Errorf("tier %d min_quantity must be at least 1", i+1)
+		}
+		if (tier.Price == nil) == (tier.DiscountPercent == nil) {
+			return fmt.Errorf("tier %d must have either price or discount_percent", i+1)
+		}
+		if tier.Price != nil && *tier.Price < 0 {
+			return fmt.Errorf("tier %d price cannot be negative", i+1)
+		}
+		if tier.DiscountPercent != nil && (*tier.DiscountPercent <= 0 || *tier.DiscountPercent > 100) {
+			return fmt.Errorf("tier %d discount_percent must be greater than 0 and at most 100", i+1)
+		}
+
+		key := [2]uint{tier.ProductID, uint(tier.MinQuantity)}
+		if seen[key] {
+			return fmt.Errorf("tier %d repeats min_quantity %d for product ID %d", i+1, tier.MinQuantity, tier.ProductID)
+		}
+		seen[key] = true
+
+		if _, err := s.productRepo.FindByID(tier.ProductID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("product ID %d not found", tier.ProductID)
+			}
+			return err
+		}
+	}
+	return nil
+}
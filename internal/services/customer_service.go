@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gocats/internal/models"
+	"gocats/internal/repository"
+	"net/mail"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type CustomerService interface {
+	CreateCustomer(name, phone, email, notes string, groupID *uint) (*models.Customer, error)
+	GetAllCustomers(search string, opts repository.QueryOptions) ([]models.Customer, *repository.Page, error)
+	GetCustomerByID(id uint) (*models.Customer, error)
+	UpdateCustomer(id uint, name, phone, email, notes string, groupID *uint) (*models.Customer, error)
+	DeleteCustomer(id uint) error
+	GetPurchaseHistory(id uint, opts repository.QueryOptions) (*models.CustomerPurchaseHistory, *repository.Page, error)
+}
+
+type customerService struct {
+	repo      repository.CustomerRepository
+	groupRepo repository.CustomerGroupRepository
+}
+
+func NewCustomerService(repo repository.CustomerRepository, groupRepo repository.CustomerGroupRepository) CustomerService {
+	return &customerService{repo: repo, groupRepo: groupRepo}
+}
+
+func (s *customerService) CreateCustomer(name, phone, email, notes string, groupID *uint) (*models.Customer, error) {
+	customer := &models.Customer{
+		Name:            strings.TrimSpace(name),
+		Phone:           strings.TrimSpace(phone),
+		Email:           strings.TrimSpace(email),
+		Notes:           notes,
+		CustomerGroupID: groupID,
+	}
+	if err := s.validate(customer); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(customer); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(customer.ID)
+}
+
+// GetAllCustomers lists customers, optionally only those whose name, phone or email contains search
+func (s *customerService) GetAllCustomers(search string, opts repository.QueryOptions) ([]models.Customer, *repository.Page, error) {
+	return s.repo.FindAll(strings.TrimSpace(search), opts)
+}
+
+func (s *customerService) GetCustomerByID(id uint) (*models.Customer, error) {
+	if id == 0 {
+		return nil, errors.New("customer ID cannot be zero")
+	}
+
+	customer, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("customer not found")
+		}
+		return nil, err
+	}
+	return customer, nil
+}
+
+// UpdateCustomer changes the name, phone, email, notes and group of a customer.
+// Empty name keeps the current name, a nil group removes the customer from their group.
+func (s *customerService) UpdateCustomer(id uint, name, phone, email, notes string, groupID *uint) (*models.Customer, error) {
+	customer, err := s.GetCustomerByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name = strings.TrimSpace(name); name != "" {
+		customer.Name = name
+	}
+	customer.Phone = strings.TrimSpace(phone)
+	customer.Email = strings.TrimSpace(email)
+	customer.Notes = notes
+	customer.CustomerGroupID = groupID
+	if err := s.validate(customer); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(customer); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
+}
+
+func (s *customerService) DeleteCustomer(id uint) error {
+	if _, err := s.GetCustomerByID(id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// GetPurchaseHistory returns the lifetime value, last purchase date and a page
+// of transactions of a customer
+func (s *customerService) GetPurchaseHistory(id uint, opts repository.QueryOptions) (*models.CustomerPurchaseHistory, *repository.Page, error) {
+	customer, err := s.GetCustomerByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stats, err := s.repo.GetStats(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transactions, page, err := s.repo.FindTransactions(id, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &models.CustomerPurchaseHistory{
+		Customer:     *customer,
+		Stats:        *stats,
+		Transactions: transactions,
+	}, page, nil
+}
+
+func (s *customerService) validate(customer *models.Customer) error {
+	if customer.Name == "" {
+		return errors.New("customer name cannot be empty")
+	}
+	if customer.Email != "" {
+		if _, err := mail.ParseAddress(customer.Email); err != nil {
+			return errors.New("invalid customer email")
+		}
+	}
+	if customer.CustomerGroupID != nil {
+		if _, err := s.groupRepo.FindByID(*customer.CustomerGroupID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("customer group ID %d not found", *customer.CustomerGroupID)
+			}
+			return err
+		}
+	}
+	return nil
+}
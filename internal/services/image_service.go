@@ -0,0 +1,217 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gocats/internal/imaging"
+	"gocats/internal/models"
+	"gocats/internal/repository"
+	"gocats/internal/storage"
+	"log"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// ThumbnailSize is the maximum width and height of generated thumbnails in pixels
+const ThumbnailSize = 300
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type ImageService interface {
+	UploadImage(productID uint, data []byte) (*models.ProductImage, error)
+	GetImages(productID uint) ([]models.ImageResponse, error)
+	ReorderImages(productID uint, imageIDs []uint) ([]models.ImageResponse, error)
+	DeleteImage(productID, imageID uint) error
+}
+
+type imageService struct {
+	imageRepo   repository.ImageRepository
+	productRepo repository.ProductRepository
+	storage     storage.Storage
+}
+
+func NewImageService(
+	imageRepo repository.ImageRepository,
+	productRepo repository.ProductRepository,
+	storage storage.Storage) ImageService {
+	return &imageService{
+		imageRepo:   imageRepo,
+		productRepo: productRepo,
+		storage:     storage,
+	}
+}
+
+func (s *imageService) UploadImage(productID uint, data []byte) (*models.ProductImage, error) {
+	if err := s.checkProduct(productID); err != nil {
+		return nil, err
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image type %s, use JPEG, PNG or GIF", contentType)
+	}
+
+	thumbnail, err := imaging.Thumbnail(bytes.NewReader(data), ThumbnailSize)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("products/%d/%s%s", productID, name, ext)
+	thumbnailKey := fmt.Sprintf("products/%d/%s_thumb.jpg", productID, name)
+
+	if err := s.storage.Put(key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	if err := s.storage.Put(thumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err != nil {
+		s.removeFiles(key)
+		return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+
+	position, err := s.imageRepo.NextPosition(productID)
+	if err != nil {
+		s.removeFiles(key, thumbnailKey)
+		return nil, err
+	}
+
+	image := &models.ProductImage{
+		ProductID:    productID,
+		Key:          key,
+		ThumbnailKey: thumbnailKey,
+		URL:          s.storage.URL(key),
+		ThumbnailURL: s.storage.URL(thumbnailKey),
+		ContentType:  contentType,
+		Position:     position,
+	}
+
+	if err := s.imageRepo.Create(image); err != nil {
+		s.removeFiles(key, thumbnailKey)
+		return nil, err
+	}
+
+	return image, nil
+}
+
+func (s *imageService) GetImages(productID uint) ([]models.ImageResponse, error) {
+	if err := s.checkProduct(productID); err != nil {
+		return nil, err
+	}
+
+	images, err := s.imageRepo.FindByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.ImageResponse, len(images))
+	for i, image := range images {
+		responses[i] = toImageResponse(image)
+	}
+	return responses, nil
+}
+
+// ReorderImages sets the display order of a product's images. imageIDs must
+// list every image of the product exactly once.
+func (s *imageService) ReorderImages(productID uint, imageIDs []uint) ([]models.ImageResponse, error) {
+	if err := s.checkProduct(productID); err != nil {
+		return nil, err
+	}
+
+	images, err := s.imageRepo.FindByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(imageIDs) != len(images) {
+		return nil, fmt.Errorf("image order must list all %d images of the product", len(images))
+	}
+
+	existing := make(map[uint]bool, len(images))
+	for _, image := range images {
+		existing[image.ID] = true
+	}
+	for _, id := range imageIDs {
+		if !existing[id] {
+			return nil, fmt.Errorf("image ID %d does not belong to the product or is listed twice", id)
+		}
+		delete(existing, id)
+	}
+
+	if err := s.imageRepo.UpdatePositions(productID, imageIDs); err != nil {
+		return nil, err
+	}
+
+	return s.GetImages(productID)
+}
+
+func (s *imageService) DeleteImage(productID, imageID uint) error {
+	image, err := s.imageRepo.FindByID(imageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("image not found")
+		}
+		return err
+	}
+
+	if image.ProductID != productID {
+		return errors.New("image not found")
+	}
+
+	if err := s.imageRepo.Delete(imageID); err != nil {
+		return err
+	}
+
+	s.removeFiles(image.Key, image.ThumbnailKey)
+	return nil
+}
+
+func (s *imageService) checkProduct(productID uint) error {
+	if productID == 0 {
+		return errors.New("product ID cannot be zero")
+	}
+
+	if _, err := s.productRepo.FindByID(productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("product not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// removeFiles deletes stored files, logging failures since the database is the source of truth
+func (s *imageService) removeFiles(keys ...string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(key); err != nil {
+			log.Printf("warning: failed to delete %s from storage: %v", key, err)
+		}
+	}
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func toImageResponse(image models.ProductImage) models.ImageResponse {
+	return models.ImageResponse{
+		ID:           image.ID,
+		URL:          image.URL,
+		ThumbnailURL: image.ThumbnailURL,
+		Position:     image.Position,
+	}
+}
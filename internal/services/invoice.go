@@ -0,0 +1,46 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Invoice sequences restart every day or every year
+const (
+	InvoiceResetDaily  = "daily"
+	InvoiceResetYearly = "yearly"
+)
+
+// InvoiceRules formats invoice numbers as the prefix, store code, date and a
+// zero-padded sequence joined by Separator, e.g. INV-S01-20261019-00042.
+// Empty parts are left out.
+type InvoiceRules struct {
+	Prefix    string
+	StoreCode string
+	Reset     string
+	Digits    int
+	Separator string
+}
+
+// series returns the invoice number before the sequence at t. Every series
+// counts from 1.
+func (r InvoiceRules) series(t time.Time) string {
+	date := t.Format("20060102")
+	if r.Reset == InvoiceResetYearly {
+		date = t.Format("2006")
+	}
+
+	var parts []string
+	for _, part := range []string{r.Prefix, r.StoreCode, date} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, r.Separator)
+}
+
+// number formats the invoice number of a sequence in a series
+func (r InvoiceRules) number(series string, sequence int64) string {
+	return series + r.Separator + fmt.Sprintf("%0*d", r.Digits, sequence)
+}
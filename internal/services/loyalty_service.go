@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"gocats/internal/models"
+	"gocats/internal/repository"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// LoyaltyRules sets how many points customers earn per currency unit paid and
+// how much a point is worth when redeemed
+type LoyaltyRules struct {
+	EarnRate   float64
+	PointValue float64
+}
+
+// earnedPoints returns the points earned for an amount paid, rounded down
+func (r LoyaltyRules) earnedPoints(amount float64) int {
+	if r.EarnRate <= 0 || amount <= 0 {
+		return 0
+	}
+	return int(math.Floor(amount * r.EarnRate))
+}
+
+type LoyaltyService interface {
+	GetBalance(customerID uint) (*models.LoyaltyBalance, error)
+}
+
+type loyaltyService struct {
+	db           *gorm.DB
+	loyaltyRepo  repository.LoyaltyRepository
+	customerRepo repository.CustomerRepository
+	rules        LoyaltyRules
+}
+
+func NewLoyaltyService(
+	db *gorm.DB,
+	loyaltyRepo repository.LoyaltyRepository,
+	customerRepo repository.CustomerRepository,
+	rules LoyaltyRules) LoyaltyService {
+	return &loyaltyService{
+		db:           db,
+		loyaltyRepo:  loyaltyRepo,
+		customerRepo: customerRepo,
+		rules:        rules,
+	}
+}
+
+// GetBalance returns the points balance of a customer, its redemption value and the ledger
+func (s *loyaltyService) GetBalance(customerID uint) (*models.LoyaltyBalance, error) {
+	if _, err := s.customerRepo.FindByID(customerID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("customer not found")
+		}
+		return nil, err
+	}
+
+	points, err := s.loyaltyRepo.GetBalance(s.db, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.loyaltyRepo.FindByCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoyaltyBalance{
+		CustomerID: customerID,
+		Points:     points,
+		Value:      roundMoney(float64(points) * s.rules.PointValue),
+		Entries:    entries,
+	}, nil
+}
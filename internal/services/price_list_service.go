@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gocats/internal/models"
+	"gocats/internal/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PriceListService interface {
+	CreatePriceList(name string, startsAt, endsAt time.Time, entries []models.PriceListEntry) (*models.PriceList, error)
+	GetPriceLists(activeAt *time.Time) ([]models.PriceList, error)
+	GetPriceListByID(id uint) (*models.PriceList, error)
+	UpdatePriceList(id uint, name string, startsAt, endsAt time.Time, entries []models.PriceListEntry) (*models.PriceList, error)
+	DeletePriceList(id uint) error
+}
+
+type priceListService struct {
+	priceListRepo repository.PriceListRepository
+	productRepo   repository.ProductRepository
+	categoryRepo  repository.CategoryRepository
+}
+
+func NewPriceListService(
+	priceListRepo repository.PriceListRepository,
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository) PriceListService {
+	return &priceListService{
+		priceListRepo: priceListRepo,
+		productRepo:   productRepo,
+		categoryRepo:  categoryRepo,
+	}
+}
+
+func (s *priceListService) CreatePriceList(name string, startsAt, endsAt time.Time, entries []models.PriceListEntry) (*models.PriceList, error) {
+	if err := s.validate(name, startsAt, endsAt, entries); err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].ID = 0
+	}
+
+	priceList := &models.PriceList{
+		Name:     name,
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+		Entries:  entries,
+	}
+	if err := s.priceListRepo.Create(priceList); err != nil {
+		return nil, err
+	}
+	return priceList, nil
+}
+
+// GetPriceLists returns all price lists, or only those active at activeAt
+func (s *priceListService) GetPriceLists(activeAt *time.Time) ([]models.PriceList, error) {
+	return s.priceListRepo.FindAll(activeAt)
+}
+
+func (s *priceListService) GetPriceListByID(id uint) (*models.PriceList, error) {
+	if id == 0 {
+		return nil, errors.New("price list ID cannot be zero")
+	}
+
+	priceList, err := s.priceListRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("price list not found")
+		}
+		return nil, err
+	}
+	return priceList, nil
+}
+
+// UpdatePriceList changes the schedule of a price list and replaces its entries
+func (s *priceListService) UpdatePriceList(id uint, name string, startsAt, endsAt time.Time, entries []models.PriceListEntry) (*models.PriceList, error) {
+	priceList, err := s.GetPriceListByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validate(name, startsAt, endsAt, entries); err != nil {
+		return nil, err
+	}
+
+	priceList.Name = name
+	priceList.StartsAt = startsAt
+	priceList.EndsAt = endsAt
+	priceList.Entries = entries
+	if err := s.priceListRepo.Update(priceList); err != nil {
+		return nil, err
+	}
+	return s.GetPriceListByID(id)
+}
+
+func (s *priceListService) DeletePriceList(id uint) error {
+	if _, err := s.GetPriceListByID(id); err != nil {
+		return err
+	}
+	return s.priceListRepo.Delete(id)
+}
+
+// validate checks the schedule and that every entry targets one existing
+// product or category with either a fixed price or a discount
+func (s *priceListService) validate(name string, startsAt, endsAt time.Time, entries []models.PriceListEntry) error {
+	if name == "" {
+		return errors.New("price list name cannot be empty")
+	}
+	if startsAt.IsZero() || endsAt.IsZero() {
+		return errors.New("starts_at and ends_at are required")
+	}
+	if !endsAt.After(startsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if len(entries) == 0 {
+		return errors.New("price list entries cannot be empty")
+	}
+
+	for i, entry := range entries {
+		if (entry.ProductID == nil) == (entry.CategoryID == nil) {
+			return fmt.Errorf("entry %d must have either product_id or category_id", i+1)
+		}
+		if (entry.Price == nil) == (entry.DiscountPercent == nil) {
+			return fmt.Errorf("entry %d must have either price or discount_percent", i+1)
+		}
+		if entry.Price != nil && *entry.Price < 0 {
+			return fmt.Errorf("entry %d price cannot be negative", i+1)
+		}
+		if entry.DiscountPercent != nil && (*entry.DiscountPercent <= 0 || *entry.DiscountPercent > 100) {
+			return fmt.Errorf("entry %d discount_percent must be greater than 0 and at most 100", i+1)
+		}
+
+		if entry.ProductID != nil {
+			if _, err := s.productRepo.FindByID(*entry.ProductID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("product ID %d not found", *entry.ProductID)
+				}
+				return err
+			}
+			continue
+		}
+
+		if entry.Price != nil {
+			return fmt.Errorf("entry %d: a fixed price can only be set for a product, use discount_percent for categories", i+1)
+		}
+		if _, err := s.categoryRepo.FindByID(*entry.CategoryID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("category ID %d not found", *entry.CategoryID)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"gocats/internal/models"
+	"gocats/internal/repository"
+	"math"
+	"sort"
+	"time"
+)
+
+// priceRules resolves the current price of products from the price lists
+// active at a point in time. When several entries apply the lowest price wins.
+type priceRules struct {
+	byProduct  map[uint][]models.PriceListEntry
+	byCategory map[uint][]models.PriceListEntry
+	parents    map[uint]*uint
+	// tiers are the price tiers of a customer group by product, by ascending
+	// minimum quantity
+	tiers map[uint][]models.PriceTier
+}
+
+// loadPriceRules loads the price list entries active at the given time
+func loadPriceRules(priceListRepo repository.PriceListRepository, categoryRepo repository.CategoryRepository, at time.Time) (*priceRules, error) {
+	entries, err := priceListRepo.FindActiveEntries(at)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &priceRules{
+		byProduct:  make(map[uint][]models.PriceListEntry),
+		byCategory: make(map[uint][]models.PriceListEntry),
+		parents:    make(map[uint]*uint),
+	}
+	for _, entry := range entries {
+		switch {
+		case entry.ProductID != nil:
+			rules.byProduct[*entry.ProductID] = append(rules.byProduct[*entry.ProductID], entry)
+		case entry.CategoryID != nil:
+			rules.byCategory[*entry.CategoryID] = append(rules.byCategory[*entry.CategoryID], entry)
+		}
+	}
+
+	if len(rules.byCategory) > 0 {
+		categories, _, err := categoryRepo.FindAll(repository.QueryOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, category := range categories {
+			rules.parents[category.ID] = category.ParentID
+		}
+	}
+
+	return rules, nil
+}
+
+// price returns the current price of a line of the product with the given
+// regular price and the price list that set it, or nil when none applies.
+// ownPrice marks variants that override the product price.
+func (r *priceRules) price(product models.Product, regular float64, ownPrice bool) (float64, *models.PriceListRef) {
+	current, source := regular, (*models.PriceList)(nil)
+	apply := func(entries []models.PriceListEntry) {
+		for _, entry := range entries {
+			if p := entry.Apply(regular, ownPrice); p < current {
+				current, source = p, entry.PriceList
+			}
+		}
+	}
+
+	apply(r.byProduct[product.ID])
+
+	// Category entries apply to subcategories as well
+	seen := make(map[uint]bool)
+	for id := &product.CategoryID; id != nil && !seen[*id]; id = r.parents[*id] {
+		seen[*id] = true
+		apply(r.byCategory[*id])
+	}
+
+	if source == nil {
+		return regular, nil
+	}
+	return current, &models.PriceListRef{ID: source.ID, Name: source.Name, EndsAt: source.EndsAt}
+}
+
+// withTiers adds the price tiers of a customer group to the rules
+func (r *priceRules) withTiers(tiers []models.PriceTier) *priceRules {
+	r.tiers = make(map[uint][]models.PriceTier)
+	for _, tier := range tiers {
+		r.tiers[tier.ProductID] = append(r.tiers[tier.ProductID], tier)
+	}
+	for _, productTiers := range r.tiers {
+		sort.SliceStable(productTiers, func(i, j int) bool {
+			return productTiers[i].MinQuantity < productTiers[j].MinQuantity
+		})
+	}
+	return r
+}
+
+// tier returns the tier of a product with the highest minimum quantity reached
+// by quantity, or nil when none is reached
+func (r *priceRules) tier(productID uint, quantity int) *models.PriceTier {
+	var match *models.PriceTier
+	for i, tier := range r.tiers[productID] {
+		if tier.MinQuantity <= quantity {
+			match = &r.tiers[productID][i]
+		}
+	}
+	return match
+}
+
+// tierPrices returns the unit price the customer group pays from each tier of
+// the product. A price list that is cheaper than a tier still wins.
+func (r *priceRules) tierPrices(product models.Product, regular float64, ownPrice bool) []models.TierPrice {
+	tiers := r.tiers[product.ID]
+	if len(tiers) == 0 {
+		return nil
+	}
+
+	current, _ := r.price(product, regular, ownPrice)
+	prices := make([]models.TierPrice, len(tiers))
+	for i, tier := range tiers {
+		prices[i] = models.TierPrice{
+			TierID:      tier.ID,
+			MinQuantity: tier.MinQuantity,
+			Price:       math.Min(tier.Apply(regular, ownPrice), current),
+		}
+	}
+	return prices
+}
+
+// applyTiers reprices the lines with the customer group tier reached by the
+// quantity of their product, counted over all lines of the product, when the
+// tier is cheaper than the price list price
+func (r *priceRules) applyTiers(lines []checkoutLine) {
+	if len(r.tiers) == 0 {
+		return
+	}
+
+	quantities := make(map[uint]int)
+	for _, line := range lines {
+		quantities[line.detail.ProductID] += line.detail.Quantity
+	}
+
+	for _, line := range lines {
+		tier := r.tier(line.detail.ProductID, quantities[line.detail.ProductID])
+		if tier == nil {
+			continue
+		}
+		if price := tier.Apply(line.regular, line.ownPrice); price < line.detail.UnitPrice {
+			tierID := tier.ID
+			line.detail.UnitPrice = price
+			line.detail.Subtotal = price * float64(line.detail.Quantity)
+			line.detail.PriceListID = nil
+			line.detail.PriceTierID = &tierID
+		}
+	}
+}
@@ -2,36 +2,63 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"gocats/internal/models"
 	"gocats/internal/repository"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type ProductService interface {
-	CreateProduct(name, description string, price float64, stock int, categoryID uint) (*models.Product, error)
-	GetAllProducts(name string) ([]models.ProductResponse, error)
-	GetProductByID(id uint) (*models.ProductResponse, error)
-	GetProductsByCategoryID(categoryID uint) ([]models.ProductResponse, error)
-	UpdateProduct(id uint, name, description string, price float64, stock int, categoryID uint) (*models.Product, error)
+	CreateProduct(name, description string, price float64, stock int, categoryID uint, attributes map[string]interface{}) (*models.Product, error)
+	GetAllProducts(filter repository.ProductFilter, includeDescendants bool, opts repository.QueryOptions) ([]models.ProductResponse, *repository.Page, error)
+	SearchProducts(term string, filter repository.ProductFilter, includeDescendants bool, opts repository.QueryOptions) ([]models.ProductSearchResult, *repository.Page, error)
+	GetProductByID(id uint, at time.Time, groupID *uint) (*models.ProductResponse, error)
+	UpdateProduct(id uint, name, description string, price *float64, stock *int, categoryID uint, attributes map[string]interface{}, actor string) (*models.Product, error)
 	DeleteProduct(id uint) error
+	SaveProductOption(productID uint, name string, values []string) (*models.ProductOption, error)
+	AddVariant(productID uint, sku string, options map[string]string, price *float64, stock int) (*models.ProductVariant, error)
+	UpdateVariant(productID, variantID uint, sku string, price *float64, stock int, actor string) (*models.ProductVariant, error)
+	DeleteVariant(productID, variantID uint) error
+	SetBundleComponents(bundleID uint, components []models.BundleComponentInput) (*models.ProductResponse, error)
+	GetArchivedProducts() ([]models.ProductResponse, error)
+	RestoreProduct(id uint) (*models.ProductResponse, error)
+	GetPriceHistory(productID uint) ([]models.PriceChange, error)
+	GetPriceChanges(startDate, endDate string) ([]models.PriceChange, error)
 }
 
 type productService struct {
-	productRepo  repository.ProductRepository
-	categoryRepo repository.CategoryRepository
+	productRepo      repository.ProductRepository
+	categoryRepo     repository.CategoryRepository
+	variantRepo      repository.VariantRepository
+	bundleRepo       repository.BundleRepository
+	priceListRepo    repository.PriceListRepository
+	priceHistoryRepo repository.PriceHistoryRepository
+	groupRepo        repository.CustomerGroupRepository
 }
 
 func NewProductService(
 	productRepo repository.ProductRepository,
-	categoryRepo repository.CategoryRepository) ProductService {
+	categoryRepo repository.CategoryRepository,
+	variantRepo repository.VariantRepository,
+	bundleRepo repository.BundleRepository,
+	priceListRepo repository.PriceListRepository,
+	priceHistoryRepo repository.PriceHistoryRepository,
+	groupRepo repository.CustomerGroupRepository) ProductService {
 	return &productService{
-		productRepo:  productRepo,
-		categoryRepo: categoryRepo,
+		productRepo:      productRepo,
+		categoryRepo:     categoryRepo,
+		variantRepo:      variantRepo,
+		bundleRepo:       bundleRepo,
+		priceListRepo:    priceListRepo,
+		priceHistoryRepo: priceHistoryRepo,
+		groupRepo:        groupRepo,
 	}
 }
 
-func (s *productService) CreateProduct(name, description string, price float64, stock int, categoryID uint) (*models.Product, error) {
+func (s *productService) CreateProduct(name, description string, price float64, stock int, categoryID uint, attributes map[string]interface{}) (*models.Product, error) {
 	// Implementation goes here
 	if name == "" {
 		return nil, errors.New("product name cannot be empty")
@@ -45,7 +72,7 @@ func (s *productService) CreateProduct(name, description string, price float64,
 		return nil, errors.New("product stock cannot be negative")
 	}
 
-	_, err := s.categoryRepo.FindByID(categoryID)
+	category, err := s.categoryRepo.FindByID(categoryID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("category not found")
@@ -53,11 +80,17 @@ func (s *productService) CreateProduct(name, description string, price float64,
 		return nil, err
 	}
 
+	if err := validateAttributes(category.AttributeSchema, attributes); err != nil {
+		return nil, err
+	}
+
 	product := &models.Product{
-		Name:       name,
-		Price:      price,
-		Stock:      stock,
-		CategoryID: categoryID,
+		Name:        name,
+		Description: description,
+		Attributes:  models.JSONMap(attributes),
+		Price:       price,
+		Stock:       stock,
+		CategoryID:  categoryID,
 	}
 
 	if err := s.productRepo.Create(product); err != nil {
@@ -67,44 +100,129 @@ func (s *productService) CreateProduct(name, description string, price float64,
 	return product, nil
 }
 
-func (s *productService) GetAllProducts(name string) ([]models.ProductResponse, error) {
-	var products []models.Product
-	var err error
+// GetAllProducts lists the products matching the filter. With includeDescendants
+// products of subcategories of the filtered categories match as well.
+func (s *productService) GetAllProducts(filter repository.ProductFilter, includeDescendants bool, opts repository.QueryOptions) ([]models.ProductResponse, *repository.Page, error) {
+	filter, err := s.resolveCategoryFilter(filter, includeDescendants)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if name != "" {
-		products, err = s.productRepo.FindByName(name)
-	} else {
-		products, err = s.productRepo.FindAll()
+	products, page, err := s.productRepo.Find(filter, opts)
+	if err != nil {
+		return nil, nil, err
 	}
 
+	responses, err := s.toResponses(products, time.Now(), nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return responses, page, nil
+}
 
-	responses := make([]models.ProductResponse, len(products))
-	for i, product := range products {
-		var cat *models.Category
-		if product.Category.ID != 0 || product.Category.Name != "" {
-			cat = &models.Category{
-				ID:          product.Category.ID,
-				Name:        product.Category.Name,
-				Description: product.Category.Description,
+// SearchProducts finds products by name, description, SKU or category name,
+// tolerating typos, and ranks them by relevance. The filter narrows the results.
+func (s *productService) SearchProducts(term string, filter repository.ProductFilter, includeDescendants bool, opts repository.QueryOptions) ([]models.ProductSearchResult, *repository.Page, error) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return nil, nil, errors.New("search term cannot be empty")
+	}
+
+	filter, err := s.resolveCategoryFilter(filter, includeDescendants)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hits, page, err := s.productRepo.Search(term, filter, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	products := make([]models.Product, len(hits))
+	for i, hit := range hits {
+		products[i] = hit.Product
+	}
+	responses, err := s.toResponses(products, time.Now(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]models.ProductSearchResult, len(hits))
+	for i, hit := range hits {
+		results[i] = models.ProductSearchResult{
+			Product: responses[i],
+			Rank:    hit.Rank,
+			Highlights: models.SearchHighlights{
+				Name:        hit.NameHighlight,
+				Description: hit.DescriptionHighlight,
+				SKU:         hit.MatchedSKU,
+			},
+		}
+	}
+	return results, page, nil
+}
+
+// resolveCategoryFilter checks that every category of the filter exists and,
+// with includeDescendants, adds their subcategories
+func (s *productService) resolveCategoryFilter(filter repository.ProductFilter, includeDescendants bool) (repository.ProductFilter, error) {
+	var categoryIDs []uint
+	for _, categoryID := range filter.CategoryIDs {
+		if _, err := s.categoryRepo.FindByID(categoryID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return filter, errors.New("category not found")
 			}
+			return filter, err
 		}
-		responses[i] = models.ProductResponse{
-			ID:         product.ID,
-			Name:       product.Name,
-			Price:      product.Price,
-			Stock:      product.Stock,
-			CategoryID: product.CategoryID,
-			Category:   cat,
+		if !includeDescendants {
+			categoryIDs = append(categoryIDs, categoryID)
+			continue
+		}
+		descendantIDs, err := s.categoryRepo.FindDescendantIDs(categoryID)
+		if err != nil {
+			return filter, err
 		}
+		categoryIDs = append(categoryIDs, descendantIDs...)
 	}
+	filter.CategoryIDs = categoryIDs
+	return filter, nil
+}
 
-	return responses, nil
+// GetProductByID returns a product with its current price at the given time
+// and, when groupID is set, the tier prices of that customer group
+func (s *productService) GetProductByID(id uint, at time.Time, groupID *uint) (*models.ProductResponse, error) {
+	if id == 0 {
+		return nil, errors.New("product ID cannot be zero")
+	}
+
+	product, err := s.productRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+
+	var group *models.CustomerGroup
+	if groupID != nil {
+		group, err = s.groupRepo.FindByID(*groupID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("customer group not found")
+			}
+			return nil, err
+		}
+	}
+
+	responses, err := s.toResponses([]models.Product{*product}, at, group)
+	if err != nil {
+		return nil, err
+	}
+	return &responses[0], nil
 }
 
-func (s *productService) GetProductByID(id uint) (*models.ProductResponse, error) {
+// UpdateProduct updates a product. A nil price or stock keeps the current
+// value. A price change is recorded with the actor who made it.
+func (s *productService) UpdateProduct(id uint, name, description string, price *float64, stock *int, categoryID uint, attributes map[string]interface{}, actor string) (*models.Product, error) {
 	if id == 0 {
 		return nil, errors.New("product ID cannot be zero")
 	}
@@ -117,128 +235,563 @@ func (s *productService) GetProductByID(id uint) (*models.ProductResponse, error
 		return nil, err
 	}
 
-	var cat *models.Category
-	if product.Category.ID != 0 || product.Category.Name != "" {
-		cat = &models.Category{
-			ID:          product.Category.ID,
-			Name:        product.Category.Name,
-			Description: product.Category.Description,
+	if name != "" {
+		product.Name = name
+	}
+
+	if description != "" {
+		product.Description = description
+	}
+
+	oldPrice := product.Price
+	if price != nil {
+		if *price < 0 {
+			return nil, errors.New("product price cannot be negative")
+		}
+		product.Price = *price
+	}
+
+	if stock != nil {
+		if *stock < 0 {
+			return nil, errors.New("product stock cannot be negative")
+		}
+		product.Stock = *stock
+	}
+
+	if categoryID > 0 {
+		_, err := s.categoryRepo.FindByID(categoryID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("category not found")
+			}
+			return nil, err
 		}
+		product.CategoryID = categoryID
+	}
+
+	if attributes != nil {
+		product.Attributes = models.JSONMap(attributes)
+	}
+
+	// Validate against the schema of the (possibly new) category
+	category, err := s.categoryRepo.FindByID(product.CategoryID)
+	if err != nil {
+		return nil, err
 	}
+	if err := validateAttributes(category.AttributeSchema, product.Attributes); err != nil {
+		return nil, err
+	}
+	product.Category = *category
 
-	response := &models.ProductResponse{
-		ID:         product.ID,
-		Name:       product.Name,
-		Price:      product.Price,
-		Stock:      product.Stock,
-		CategoryID: product.CategoryID,
-		Category:   cat,
+	if price != nil && product.Price != oldPrice {
+		change := newPriceChange(product.ID, nil, oldPrice, product.Price, actor)
+		if err := s.priceHistoryRepo.UpdateProduct(product, change); err != nil {
+			return nil, err
+		}
+	} else if err := s.productRepo.Update(product); err != nil {
+		return nil, err
 	}
 
-	return response, nil
+	return product, nil
+
 }
 
-func (s *productService) GetProductsByCategoryID(categoryID uint) ([]models.ProductResponse, error) {
-	if categoryID == 0 {
-		return nil, errors.New("category ID cannot be zero")
+func (s *productService) DeleteProduct(id uint) error {
+	if id == 0 {
+		return errors.New("product ID cannot be zero")
 	}
 
-	_, err := s.categoryRepo.FindByID(categoryID)
+	_, err := s.productRepo.FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("category not found")
+			return errors.New("product not found")
 		}
+		return err
+	}
+
+	return s.productRepo.Delete(id)
+}
+
+func (s *productService) SaveProductOption(productID uint, name string, values []string) (*models.ProductOption, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("option name cannot be empty")
+	}
+	if len(values) == 0 {
+		return nil, errors.New("option values cannot be empty")
+	}
+
+	if _, err := s.findProduct(productID); err != nil {
 		return nil, err
 	}
 
-	products, err := s.productRepo.FindByCategoryID(categoryID)
+	// Variants must keep pointing at values of their option types
+	variants, err := s.variantRepo.FindByProductID(productID)
 	if err != nil {
 		return nil, err
 	}
+	for _, variant := range variants {
+		value, ok := variant.Options[name]
+		if !ok {
+			return nil, fmt.Errorf("variant %s has no value for option %s, delete the variants before adding option types", variant.SKU, name)
+		}
+		if !containsString(values, value) {
+			return nil, fmt.Errorf("option value %q is used by variant %s", value, variant.SKU)
+		}
+	}
 
-	responses := make([]models.ProductResponse, len(products))
-	for i, product := range products {
-		var cat *models.Category
-		if product.Category.ID != 0 || product.Category.Name != "" {
-			cat = &models.Category{
-				ID:          product.Category.ID,
-				Name:        product.Category.Name,
-				Description: product.Category.Description,
-			}
+	option := &models.ProductOption{
+		ProductID: productID,
+		Name:      name,
+		Values:    models.StringArray(values),
+	}
+
+	if err := s.variantRepo.SaveOption(option); err != nil {
+		return nil, err
+	}
+
+	return option, nil
+}
+
+func (s *productService) AddVariant(productID uint, sku string, options map[string]string, price *float64, stock int) (*models.ProductVariant, error) {
+	if sku == "" {
+		return nil, errors.New("variant SKU cannot be empty")
+	}
+
+	if price != nil && *price < 0 {
+		return nil, errors.New("variant price cannot be negative")
+	}
+
+	if stock < 0 {
+		return nil, errors.New("variant stock cannot be negative")
+	}
+
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateVariantOptions(product.Options, options); err != nil {
+		return nil, err
+	}
+
+	for _, existing := range product.Variants {
+		if sameOptions(existing.Options, options) {
+			return nil, fmt.Errorf("variant with the same options already exists (SKU %s)", existing.SKU)
 		}
-		responses[i] = models.ProductResponse{
-			ID:         product.ID,
-			Name:       product.Name,
-			Price:      product.Price,
-			Stock:      product.Stock,
-			CategoryID: product.CategoryID,
-			Category:   cat,
+	}
+
+	variant := &models.ProductVariant{
+		ProductID: productID,
+		SKU:       sku,
+		Options:   models.StringMap(options),
+		Price:     price,
+		Stock:     stock,
+	}
+
+	if err := s.variantRepo.Create(variant); err != nil {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+// UpdateVariant updates a variant. A change of its price override is recorded
+// with the actor who made it.
+func (s *productService) UpdateVariant(productID, variantID uint, sku string, price *float64, stock int, actor string) (*models.ProductVariant, error) {
+	variant, err := s.findVariant(productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.findProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	oldPrice := variant.EffectivePrice(product.Price)
+
+	if sku != "" {
+		variant.SKU = sku
+	}
+
+	if price != nil {
+		if *price < 0 {
+			return nil, errors.New("variant price cannot be negative")
 		}
+		variant.Price = price
 	}
 
-	return responses, nil
+	if stock >= 0 {
+		variant.Stock = stock
+	}
+
+	if newPrice := variant.EffectivePrice(product.Price); newPrice != oldPrice {
+		change := newPriceChange(productID, &variant.ID, oldPrice, newPrice, actor)
+		if err := s.priceHistoryRepo.UpdateVariant(variant, change); err != nil {
+			return nil, err
+		}
+		return variant, nil
+	}
+
+	if err := s.variantRepo.Update(variant); err != nil {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+func (s *productService) DeleteVariant(productID, variantID uint) error {
+	if _, err := s.findVariant(productID, variantID); err != nil {
+		return err
+	}
+
+	return s.variantRepo.Delete(variantID)
+}
+
+func (s *productService) GetArchivedProducts() ([]models.ProductResponse, error) {
+	products, err := s.productRepo.FindArchived()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toResponses(products, time.Now(), nil)
 }
 
-func (s *productService) UpdateProduct(id uint, name, description string, price float64, stock int, categoryID uint) (*models.Product, error) {
+// RestoreProduct brings an archived product back into listings and checkout
+func (s *productService) RestoreProduct(id uint) (*models.ProductResponse, error) {
 	if id == 0 {
 		return nil, errors.New("product ID cannot be zero")
 	}
 
-	product, err := s.productRepo.FindByID(id)
-
+	product, err := s.productRepo.FindArchivedByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("product not found")
+			return nil, errors.New("archived product not found")
 		}
+		return nil, err
 	}
 
-	if name != "" {
-		product.Name = name
+	if _, err := s.categoryRepo.FindByID(product.CategoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("the product category is archived, restore the category first")
+		}
+		return nil, err
 	}
 
-	if stock >= 0 {
-		product.Stock = stock
+	if err := s.productRepo.Restore(id); err != nil {
+		return nil, err
 	}
 
-	if price >= 0 {
-		product.Price = price
+	return s.GetProductByID(id, time.Now(), nil)
+}
+
+// SetBundleComponents replaces the components of a bundle product. An empty list
+// turns the bundle back into a regular product.
+func (s *productService) SetBundleComponents(bundleID uint, components []models.BundleComponentInput) (*models.ProductResponse, error) {
+	bundle, err := s.findProduct(bundleID)
+	if err != nil {
+		return nil, err
 	}
 
-	if stock >= 0 {
-		product.Stock = stock
+	if bundle.HasVariants() {
+		return nil, errors.New("a product with variants cannot be a bundle")
 	}
 
-	if categoryID > 0 {
-		_, err := s.categoryRepo.FindByID(categoryID)
+	items := make([]models.BundleItem, 0, len(components))
+	seen := make(map[uint]bool)
+	for _, component := range components {
+		if component.Quantity <= 0 {
+			return nil, fmt.Errorf("invalid quantity for component product ID %d", component.ProductID)
+		}
+		if component.ProductID == bundleID {
+			return nil, errors.New("a bundle cannot contain itself")
+		}
+		if seen[component.ProductID] {
+			return nil, fmt.Errorf("component product ID %d is listed more than once", component.ProductID)
+		}
+		seen[component.ProductID] = true
+
+		product, err := s.productRepo.FindByID(component.ProductID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil, errors.New("category not found")
+				return nil, fmt.Errorf("component product ID %d not found", component.ProductID)
 			}
 			return nil, err
 		}
-		product.CategoryID = categoryID
+		if product.IsBundle {
+			return nil, fmt.Errorf("component %s is a bundle, nested bundles are not supported", product.Name)
+		}
+		if product.HasVariants() {
+			return nil, fmt.Errorf("component %s has variants and cannot be used in a bundle", product.Name)
+		}
+
+		items = append(items, models.BundleItem{
+			ComponentID: component.ProductID,
+			Quantity:    component.Quantity,
+		})
 	}
 
-	if err := s.productRepo.Update(product); err != nil {
+	if err := s.bundleRepo.ReplaceItems(bundleID, items); err != nil {
 		return nil, err
 	}
 
-	return product, nil
+	return s.GetProductByID(bundleID, time.Now(), nil)
+}
+
+// GetPriceHistory returns the price changes of a product and its variants, newest first
+func (s *productService) GetPriceHistory(productID uint) ([]models.PriceChange, error) {
+	if _, err := s.findProduct(productID); err != nil {
+		return nil, err
+	}
+	return s.priceHistoryRepo.FindByProductID(productID)
+}
 
+// GetPriceChanges returns all price changes in the date range
+func (s *productService) GetPriceChanges(startDate, endDate string) ([]models.PriceChange, error) {
+	if startDate == "" || endDate == "" {
+		return nil, errors.New("start_date and end_date are required")
+	}
+	return s.priceHistoryRepo.FindByDateRange(startDate, endDate)
 }
 
-func (s *productService) DeleteProduct(id uint) error {
+// newPriceChange builds the history record of a price change. Changes made
+// without an identified actor are attributed to "system".
+func newPriceChange(productID uint, variantID *uint, oldPrice, newPrice float64, actor string) *models.PriceChange {
+	if actor == "" {
+		actor = "system"
+	}
+	return &models.PriceChange{
+		ProductID: productID,
+		VariantID: variantID,
+		OldPrice:  oldPrice,
+		NewPrice:  newPrice,
+		ChangedBy: actor,
+		ChangedAt: time.Now(),
+	}
+}
+
+func (s *productService) findProduct(id uint) (*models.Product, error) {
 	if id == 0 {
-		return errors.New("product ID cannot be zero")
+		return nil, errors.New("product ID cannot be zero")
 	}
 
-	_, err := s.productRepo.FindByID(id)
+	product, err := s.productRepo.FindByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("product not found")
+			return nil, errors.New("product not found")
 		}
-		return err
+		return nil, err
 	}
+	return product, nil
+}
 
-	return s.productRepo.Delete(id)
+func (s *productService) findVariant(productID, variantID uint) (*models.ProductVariant, error) {
+	variant, err := s.variantRepo.FindByID(variantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("variant not found")
+		}
+		return nil, err
+	}
+
+	if variant.ProductID != productID {
+		return nil, errors.New("variant not found")
+	}
+	return variant, nil
+}
+
+// validateVariantOptions checks that a variant sets exactly one allowed value for every option type
+func validateVariantOptions(optionTypes []models.ProductOption, values map[string]string) error {
+	if len(optionTypes) == 0 {
+		return errors.New("product has no option types, add options before creating variants")
+	}
+
+	if len(values) != len(optionTypes) {
+		return fmt.Errorf("variant must set a value for each of the %d product options", len(optionTypes))
+	}
+
+	for _, option := range optionTypes {
+		value, ok := values[option.Name]
+		if !ok {
+			return fmt.Errorf("variant is missing a value for option %s", option.Name)
+		}
+		if !option.HasValue(value) {
+			return fmt.Errorf("invalid value %q for option %s", value, option.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateAttributes checks custom product attributes against the attribute schema
+// of the product category. Categories without a schema accept any attributes.
+func validateAttributes(schema models.AttributeSchema, attributes map[string]interface{}) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	for _, def := range schema {
+		if _, ok := attributes[def.Name]; def.Required && !ok {
+			return fmt.Errorf("attribute %s is required", def.Name)
+		}
+	}
+
+	for name, value := range attributes {
+		def, ok := schema.Find(name)
+		if !ok {
+			return fmt.Errorf("attribute %s is not defined for this category", name)
+		}
+
+		switch def.Type {
+		case models.AttributeTypeNumber:
+			if _, ok := value.(float64); !ok {
+				return fmt.Errorf("attribute %s must be a number", name)
+			}
+		case models.AttributeTypeBoolean:
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("attribute %s must be a boolean", name)
+			}
+		default:
+			str, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("attribute %s must be a string", name)
+			}
+			if len(def.Allowed) > 0 && !containsString(def.Allowed, str) {
+				return fmt.Errorf("invalid value %q for attribute %s", str, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sameOptions(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// toResponses maps products to API responses with their current price at the
+// given time, the tier prices of the customer group if any, and adds the
+// category breadcrumbs
+func (s *productService) toResponses(products []models.Product, at time.Time, group *models.CustomerGroup) ([]models.ProductResponse, error) {
+	responses := make([]models.ProductResponse, len(products))
+	if len(products) == 0 {
+		return responses, nil
+	}
+
+	categories, _, err := s.categoryRepo.FindAll(repository.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	rules, err := loadPriceRules(s.priceListRepo, s.categoryRepo, at)
+	if err != nil {
+		return nil, err
+	}
+	if group != nil {
+		rules.withTiers(group.Tiers)
+	}
+
+	for i, product := range products {
+		responses[i] = toProductResponse(product, rules)
+		responses[i].Breadcrumbs = categoryBreadcrumbs(product.CategoryID, byID)
+	}
+
+	return responses, nil
+}
+
+// toProductResponse maps a product with its preloaded relations to the API response,
+// grouping variants under their parent product
+func toProductResponse(product models.Product, rules *priceRules) models.ProductResponse {
+	var cat *models.Category
+	if product.Category.ID != 0 || product.Category.Name != "" {
+		cat = &models.Category{
+			ID:          product.Category.ID,
+			Name:        product.Category.Name,
+			Description: product.Category.Description,
+		}
+	}
+
+	response := models.ProductResponse{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Attributes:  product.Attributes,
+		Price:       product.Price,
+		Stock:       product.AvailableStock(),
+		CategoryID:  product.CategoryID,
+		Category:    cat,
+		Options:     product.Options,
+		CreatedAt:   product.CreatedAt,
+		UpdatedAt:   product.UpdatedAt,
+	}
+
+	response.CurrentPrice, response.PriceList = rules.price(product, product.Price, false)
+	response.PriceTiers = rules.tierPrices(product, product.Price, false)
+
+	if product.DeletedAt.Valid {
+		archivedAt := product.DeletedAt.Time
+		response.ArchivedAt = &archivedAt
+	}
+
+	if len(product.Images) > 0 {
+		response.Images = make([]models.ImageResponse, len(product.Images))
+		for i, image := range product.Images {
+			response.Images[i] = toImageResponse(image)
+		}
+	}
+
+	if product.IsBundle {
+		// A bundle has no stock of its own, it is limited by its components
+		response.IsBundle = true
+		response.Components = make([]models.BundleComponentResponse, len(product.BundleItems))
+		for i, item := range product.BundleItems {
+			response.Components[i] = models.BundleComponentResponse{
+				ProductID: item.ComponentID,
+				Name:      item.Component.Name,
+				Quantity:  item.Quantity,
+				Stock:     item.Component.Stock,
+			}
+		}
+	}
+
+	if product.HasVariants() {
+		response.Variants = make([]models.VariantResponse, len(product.Variants))
+		for i, variant := range product.Variants {
+			price := variant.EffectivePrice(product.Price)
+			currentPrice, priceList := rules.price(product, price, variant.Price != nil)
+			response.Variants[i] = models.VariantResponse{
+				ID:           variant.ID,
+				SKU:          variant.SKU,
+				Options:      variant.Options,
+				Price:        price,
+				CurrentPrice: currentPrice,
+				PriceList:    priceList,
+				PriceTiers:   rules.tierPrices(product, price, variant.Price != nil),
+				Stock:        variant.Stock,
+			}
+		}
+	}
+
+	return response
 }
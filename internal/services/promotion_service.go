@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gocats/internal/models"
+	"gocats/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type PromotionService interface {
+	CreatePromotion(promotion models.Promotion) (*models.Promotion, error)
+	GetAllPromotions() ([]models.Promotion, error)
+	GetPromotionByID(id uint) (*models.Promotion, error)
+	UpdatePromotion(id uint, promotion models.Promotion) (*models.Promotion, error)
+	DeletePromotion(id uint) error
+}
+
+type promotionService struct {
+	promotionRepo repository.PromotionRepository
+	productRepo   repository.ProductRepository
+	categoryRepo  repository.CategoryRepository
+}
+
+func NewPromotionService(
+	promotionRepo repository.PromotionRepository,
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository) PromotionService {
+	return &promotionService{
+		promotionRepo: promotionRepo,
+		productRepo:   productRepo,
+		categoryRepo:  categoryRepo,
+	}
+}
+
+func (s *promotionService) CreatePromotion(promotion models.Promotion) (*models.Promotion, error) {
+	if err := s.validate(promotion); err != nil {
+		return nil, err
+	}
+
+	promotion.ID = 0
+	if err := s.promotionRepo.Create(&promotion); err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// GetAllPromotions returns promotions in evaluation order
+func (s *promotionService) GetAllPromotions() ([]models.Promotion, error) {
+	return s.promotionRepo.FindAll()
+}
+
+func (s *promotionService) GetPromotionByID(id uint) (*models.Promotion, error) {
+	if id == 0 {
+		return nil, errors.New("promotion ID cannot be zero")
+	}
+
+	promotion, err := s.promotionRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("promotion not found")
+		}
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// UpdatePromotion replaces the rule of a promotion
+func (s *promotionService) UpdatePromotion(id uint, promotion models.Promotion) (*models.Promotion, error) {
+	existing, err := s.GetPromotionByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validate(promotion); err != nil {
+		return nil, err
+	}
+
+	promotion.ID = existing.ID
+	promotion.CreatedAt = existing.CreatedAt
+	if err := s.promotionRepo.Update(&promotion); err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// DeletePromotion stops a promotion. It stays attributed on past transaction lines.
+func (s *promotionService) DeletePromotion(id uint) error {
+	if _, err := s.GetPromotionByID(id); err != nil {
+		return err
+	}
+	return s.promotionRepo.Delete(id)
+}
+
+// validate checks the schedule, the scope and the parameters of the promotion type
+func (s *promotionService) validate(promotion models.Promotion) error {
+	if promotion.Name == "" {
+		return errors.New("promotion name cannot be empty")
+	}
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+
+	switch promotion.Type {
+	case models.PromotionBuyXGetY:
+		if promotion.BuyQuantity <= 0 || promotion.GetQuantity <= 0 {
+			return errors.New("buy_quantity and get_quantity must be positive numbers")
+		}
+	case models.PromotionMultiBuy:
+		if promotion.DealQuantity < 2 {
+			return errors.New("deal_quantity must be at least 2")
+		}
+		if promotion.DealPrice < 0 {
+			return errors.New("deal_price cannot be negative")
+		}
+	case models.PromotionTiered:
+		if len(promotion.Tiers) == 0 {
+			return errors.New("tiers cannot be empty")
+		}
+		for _, tier := range promotion.Tiers {
+			if tier.MinQuantity <= 0 {
+				return errors.New("tier min_quantity must be a positive number")
+			}
+			if tier.DiscountPercent <= 0 || tier.DiscountPercent > 100 {
+				return errors.New("tier discount_percent must be greater than 0 and at most 100")
+			}
+		}
+	default:
+		return fmt.Errorf("promotion type must be %s, %s or %s",
+			models.PromotionBuyXGetY, models.PromotionMultiBuy, models.PromotionTiered)
+	}
+
+	for _, id := range promotion.ProductIDs {
+		if _, err := s.productRepo.FindByID(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("product ID %d not found", id)
+			}
+			return err
+		}
+	}
+	for _, id := range promotion.CategoryIDs {
+		if _, err := s.categoryRepo.FindByID(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("category ID %d not found", id)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"gocats/internal/models"
+	"gocats/internal/repository"
+	"math"
+	"sort"
+	"time"
+)
+
+// promotionEngine evaluates the promotions running at checkout time against
+// the checkout lines. Promotions are evaluated by priority and every line
+// takes part in at most one promotion, so deals never stack.
+type promotionEngine struct {
+	promotions []models.Promotion
+	parents    map[uint]*uint
+}
+
+// checkoutLine is a priced checkout line with the product it sells. regular is
+// the unit price before price lists and tiers, ownPrice marks variants that
+// override the product price.
+type checkoutLine struct {
+	detail   *models.TransactionDetail
+	product  models.Product
+	regular  float64
+	ownPrice bool
+}
+
+// promotionUnit is a single unit of a checkout line
+type promotionUnit struct {
+	line  int
+	price float64
+}
+
+func loadPromotionEngine(promotionRepo repository.PromotionRepository, categoryRepo repository.CategoryRepository, at time.Time) (*promotionEngine, error) {
+	promotions, err := promotionRepo.FindActive(at)
+	if err != nil {
+		return nil, err
+	}
+
+	engine := &promotionEngine{promotions: promotions, parents: make(map[uint]*uint)}
+	for _, promotion := range promotions {
+		if len(promotion.CategoryIDs) == 0 {
+			continue
+		}
+		categories, _, err := categoryRepo.FindAll(repository.QueryOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, category := range categories {
+			engine.parents[category.ID] = category.ParentID
+		}
+		break
+	}
+	return engine, nil
+}
+
+// apply sets the discount, subtotal and promotion of the lines
+func (e *promotionEngine) apply(lines []checkoutLine) {
+	for _, promotion := range e.promotions {
+		var pool []checkoutLine
+		for _, line := range lines {
+			if line.detail.PromotionID == nil && e.inScope(promotion, line) {
+				pool = append(pool, line)
+			}
+		}
+		if len(pool) == 0 {
+			continue
+		}
+
+		discounts, used := promotionDiscounts(promotion, pool)
+		var total float64
+		for _, discount := range discounts {
+			total += discount
+		}
+		if total <= 0 {
+			continue
+		}
+
+		// The lines the deal consumed are claimed, including the units bought
+		// to earn it; the others stay free for the next promotions
+		promotionID := promotion.ID
+		for i, line := range pool {
+			if !used[i] {
+				continue
+			}
+			discount := math.Min(roundMoney(discounts[i]), line.detail.Subtotal)
+			line.detail.PromotionID = &promotionID
+			line.detail.Discount = discount
+			line.detail.Subtotal = roundMoney(line.detail.Subtotal - discount)
+		}
+	}
+}
+
+// inScope reports whether the promotion applies to the line product, directly
+// or through its category or a parent category
+func (e *promotionEngine) inScope(promotion models.Promotion, line checkoutLine) bool {
+	if len(promotion.ProductIDs) == 0 && len(promotion.CategoryIDs) == 0 {
+		return true
+	}
+	for _, id := range promotion.ProductIDs {
+		if id == line.detail.ProductID {
+			return true
+		}
+	}
+
+	seen := make(map[uint]bool)
+	for id := &line.product.CategoryID; id != nil && !seen[*id]; id = e.parents[*id] {
+		seen[*id] = true
+		for _, categoryID := range promotion.CategoryIDs {
+			if categoryID == *id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// promotionDiscounts returns the discount of each line of the pool and whether
+// the deal consumed any units of the line, bought or discounted
+func promotionDiscounts(promotion models.Promotion, pool []checkoutLine) ([]float64, []bool) {
+	discounts := make([]float64, len(pool))
+	used := make([]bool, len(pool))
+
+	var units []promotionUnit
+	for i, line := range pool {
+		for n := 0; n < line.detail.Quantity; n++ {
+			units = append(units, promotionUnit{line: i, price: line.detail.UnitPrice})
+		}
+	}
+
+	switch promotion.Type {
+	case models.PromotionBuyXGetY:
+		// The cheapest units of every complete group are free
+		if promotion.BuyQuantity <= 0 || promotion.GetQuantity <= 0 {
+			break
+		}
+		groupSize := promotion.BuyQuantity + promotion.GetQuantity
+		sort.SliceStable(units, func(i, j int) bool { return units[i].price < units[j].price })
+		groups := len(units) / groupSize
+		free := groups * promotion.GetQuantity
+		for _, unit := range units[:free] {
+			discounts[unit.line] += unit.price
+			used[unit.line] = true
+		}
+		// The most expensive units are the ones bought
+		for _, unit := range units[len(units)-groups*promotion.BuyQuantity:] {
+			used[unit.line] = true
+		}
+
+	case models.PromotionMultiBuy:
+		// The most expensive units are grouped, the discount is shared by value
+		if promotion.DealQuantity <= 0 {
+			break
+		}
+		sort.SliceStable(units, func(i, j int) bool { return units[i].price > units[j].price })
+		groups := len(units) / promotion.DealQuantity
+		grouped := units[:groups*promotion.DealQuantity]
+		var value float64
+		for _, unit := range grouped {
+			value += unit.price
+		}
+		discount := value - float64(groups)*promotion.DealPrice
+		if discount <= 0 {
+			break
+		}
+		for _, unit := range grouped {
+			discounts[unit.line] += discount * unit.price / value
+			used[unit.line] = true
+		}
+
+	case models.PromotionTiered:
+		var percent float64
+		best := 0
+		for _, tier := range promotion.Tiers {
+			if tier.MinQuantity <= len(units) && tier.MinQuantity > best {
+				best, percent = tier.MinQuantity, tier.DiscountPercent
+			}
+		}
+		for i, line := range pool {
+			discounts[i] = line.detail.UnitPrice * float64(line.detail.Quantity) * percent / 100
+			used[i] = percent > 0
+		}
+	}
+
+	return discounts, used
+}
+
+// roundMoney rounds an amount to whole cents
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
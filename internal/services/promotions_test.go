@@ -0,0 +1,60 @@
+package services
+
+import (
+	"gocats/internal/models"
+	"testing"
+)
+
+func newPromotionLine(productID uint, quantity int, unitPrice float64) checkoutLine {
+	return checkoutLine{
+		detail: &models.TransactionDetail{
+			ProductID: productID,
+			Quantity:  quantity,
+			UnitPrice: unitPrice,
+			Subtotal:  unitPrice * float64(quantity),
+		},
+		product: models.Product{ID: productID},
+		regular: unitPrice,
+	}
+}
+
+func TestPromotionBuyLinesDoNotStack(t *testing.T) {
+	// Buy 2 shirts get the cheapest item free, then 10% off everything
+	engine := &promotionEngine{
+		promotions: []models.Promotion{
+			{ID: 1, Type: models.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1},
+			{ID: 2, Type: models.PromotionTiered, Tiers: models.PromotionTiers{{MinQuantity: 1, DiscountPercent: 10}}},
+		},
+		parents: map[uint]*uint{},
+	}
+	shirts := newPromotionLine(1, 2, 100)
+	socks := newPromotionLine(2, 1, 20)
+	hat := newPromotionLine(3, 1, 50)
+	engine.apply([]checkoutLine{shirts, socks, hat})
+
+	// Three of the four units form a group: the shirts are bought and the socks
+	// free, so only the hat left over takes the 10%
+	for _, tc := range []struct {
+		name      string
+		line      checkoutLine
+		promotion uint
+		discount  float64
+	}{
+		{"shirts", shirts, 1, 0},
+		{"socks", socks, 1, 20},
+		{"hat", hat, 2, 5},
+	} {
+		detail := tc.line.detail
+		var promotion uint
+		if detail.PromotionID != nil {
+			promotion = *detail.PromotionID
+		}
+		if promotion != tc.promotion {
+			t.Errorf("%s: promotion = %d, want %d", tc.name, promotion, tc.promotion)
+			continue
+		}
+		if detail.Discount != tc.discount {
+			t.Errorf("%s: discount = %v, want %v", tc.name, detail.Discount, tc.discount)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"gocats/internal/repository"
+	"gocats/internal/storage"
+	"log"
+	"time"
+)
+
+// PurgeRules sets how long archived items are retained before they are
+// permanently deleted
+type PurgeRules struct {
+	ProductRetention  time.Duration
+	CategoryRetention time.Duration
+}
+
+type PurgeResult struct {
+	ProductsPurged   int   `json:"products_purged"`
+	CategoriesPurged int64 `json:"categories_purged"`
+}
+
+type PurgeService interface {
+	Purge(rules PurgeRules) (*PurgeResult, error)
+}
+
+type purgeService struct {
+	productRepo  repository.ProductRepository
+	categoryRepo repository.CategoryRepository
+	storage      storage.Storage
+}
+
+func NewPurgeService(
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	storage storage.Storage) PurgeService {
+	return &purgeService{
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		storage:      storage,
+	}
+}
+
+// Purge permanently deletes archived products and categories older than their
+// retention. Products still referenced by transactions, bundles, price history
+// or promotions and categories that still have products are always kept.
+func (s *purgeService) Purge(rules PurgeRules) (*PurgeResult, error) {
+	if rules.ProductRetention < 0 || rules.CategoryRetention < 0 {
+		return nil, errors.New("retention cannot be negative")
+	}
+
+	now := time.Now()
+	result := &PurgeResult{}
+
+	products, err := s.productRepo.FindPurgeable(now.Add(-rules.ProductRetention))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+
+	if err := s.productRepo.DeletePermanently(ids); err != nil {
+		return nil, err
+	}
+	result.ProductsPurged = len(ids)
+
+	// Image rows are removed by the cascade, the stored files are removed here
+	for _, product := range products {
+		for _, image := range product.Images {
+			for _, key := range []string{image.Key, image.ThumbnailKey} {
+				if err := s.storage.Delete(key); err != nil {
+					log.Printf("warning: failed to delete %s from storage: %v", key, err)
+				}
+			}
+		}
+	}
+
+	// Categories go after products so that purged products no longer hold them
+	result.CategoriesPurged, err = s.categoryRepo.PurgeArchived(now.Add(-rules.CategoryRetention))
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
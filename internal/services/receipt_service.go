@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gocats/internal/receipt"
+	"gocats/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type ReceiptService interface {
+	// RenderReceipt returns the receipt of a transaction in the given format
+	// with its content type. openDrawer only applies to ESC/POS output.
+	RenderReceipt(transactionID uint, format string, paper receipt.Paper, openDrawer bool) ([]byte, string, error)
+	// PrintReceipt sends the ESC/POS receipt of a transaction to the network printer
+	PrintReceipt(transactionID uint, paper receipt.Paper, openDrawer bool) error
+}
+
+type receiptService struct {
+	transRepo repository.TransactionRepository
+	renderer  *receipt.Renderer
+	printer   *receipt.Printer
+}
+
+func NewReceiptService(transRepo repository.TransactionRepository, renderer *receipt.Renderer, printer *receipt.Printer) ReceiptService {
+	return &receiptService{
+		transRepo: transRepo,
+		renderer:  renderer,
+		printer:   printer,
+	}
+}
+
+func (s *receiptService) RenderReceipt(transactionID uint, format string, paper receipt.Paper, openDrawer bool) ([]byte, string, error) {
+	r, err := s.loadReceipt(transactionID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case "", receipt.FormatText:
+		body, err := s.renderer.Text(r, paper)
+		return body, "text/plain; charset=utf-8", err
+	case receipt.FormatHTML:
+		body, err := s.renderer.HTML(r)
+		return body, "text/html; charset=utf-8", err
+	case receipt.FormatPDF:
+		body, err := s.renderer.PDF(r, paper)
+		return body, "application/pdf", err
+	case receipt.FormatESCPOS:
+		return s.renderer.ESCPOS(r, paper, openDrawer), "application/octet-stream", nil
+	default:
+		return nil, "", fmt.Errorf("format must be %s, %s, %s or %s", receipt.FormatText, receipt.FormatHTML, receipt.FormatPDF, receipt.FormatESCPOS)
+	}
+}
+
+func (s *receiptService) PrintReceipt(transactionID uint, paper receipt.Paper, openDrawer bool) error {
+	if !s.printer.Enabled() {
+		return receipt.ErrNoPrinter
+	}
+	r, err := s.loadReceipt(transactionID)
+	if err != nil {
+		return err
+	}
+	return s.printer.Send(s.renderer.ESCPOS(r, paper, openDrawer))
+}
+
+// loadReceipt lays out the receipt of a transaction
+func (s *receiptService) loadReceipt(transactionID uint) (*receipt.Receipt, error) {
+	transaction, err := s.transRepo.FindByID(transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("transaction not found")
+		}
+		return nil, err
+	}
+	return receipt.New(transaction, s.renderer.Config()), nil
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"gocats/internal/models"
+	"gocats/internal/receipt"
+	"gocats/internal/repository"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReportService interface {
+	// GetXReport summarizes the business day so far without closing it
+	GetXReport() (*models.DayReport, error)
+	// CloseDay stores the next numbered Z-report, which starts a new business day
+	CloseDay(actor string) (*models.DayReport, error)
+	GetAllZReports(opts repository.QueryOptions) ([]models.DayReport, *repository.Page, error)
+	GetZReport(number int64) (*models.DayReport, error)
+	// PrintDayReport renders a report as plain text for the paper
+	PrintDayReport(report *models.DayReport, paper receipt.Paper) []byte
+}
+
+type reportService struct {
+	db         *gorm.DB
+	reportRepo repository.DayReportRepository
+	renderer   *receipt.Renderer
+}
+
+func NewReportService(db *gorm.DB, reportRepo repository.DayReportRepository, renderer *receipt.Renderer) ReportService {
+	return &reportService{
+		db:         db,
+		reportRepo: reportRepo,
+		renderer:   renderer,
+	}
+}
+
+func (s *reportService) GetXReport() (*models.DayReport, error) {
+	report := &models.DayReport{Type: models.ReportX}
+	if err := s.summarize(s.db, report); err != nil {
+		return nil, err
+	}
+	// Only Z-reports are numbered
+	report.Number = 0
+	return report, nil
+}
+
+func (s *reportService) CloseDay(actor string) (*models.DayReport, error) {
+	if actor = strings.TrimSpace(actor); actor == "" {
+		actor = "system"
+	}
+
+	report := &models.DayReport{Type: models.ReportZ, CreatedBy: actor}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.reportRepo.LockForClosing(tx); err != nil {
+			return err
+		}
+		if err := s.summarize(tx, report); err != nil {
+			return err
+		}
+		return s.reportRepo.Create(tx, report)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// summarize fills report with the business day since the last Z-report and
+// numbers it after that report
+func (s *reportService) summarize(tx *gorm.DB, report *models.DayReport) error {
+	var afterTransactionID, afterRefundID uint
+	report.Number = 1
+	last, err := s.reportRepo.FindLastZ(tx)
+	switch {
+	case err == nil:
+		afterTransactionID, afterRefundID = last.LastTransactionID, last.LastRefundID
+		report.Number = last.Number + 1
+		report.PeriodStart = last.PeriodEnd
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	report.PeriodEnd = time.Now()
+	if err := s.reportRepo.Summarize(tx, afterTransactionID, afterRefundID, report); err != nil {
+		return err
+	}
+	if report.PeriodStart.IsZero() {
+		report.PeriodStart = report.PeriodEnd
+	}
+
+	report.GrossSales = roundMoney(report.GrossSales)
+	report.Discounts = roundMoney(report.Discounts)
+	report.Refunds = roundMoney(report.Refunds)
+	report.Voids = roundMoney(report.Voids)
+	report.NetSales = roundMoney(report.GrossSales - report.Discounts - report.Refunds - report.Voids)
+	report.Taxes = roundMoney(report.Taxes)
+	report.CashRefunds = roundMoney(report.CashRefunds)
+	if report.Payments == nil {
+		report.Payments = models.PaymentMethodTotals{}
+	}
+	if report.Categories == nil {
+		report.Categories = models.CategoryTotals{}
+	}
+	return nil
+}
+
+func (s *reportService) GetAllZReports(opts repository.QueryOptions) ([]models.DayReport, *repository.Page, error) {
+	return s.reportRepo.FindAll(opts)
+}
+
+func (s *reportService) GetZReport(number int64) (*models.DayReport, error) {
+	report, err := s.reportRepo.FindByNumber(number)
+	if err != nil {
+		return nil, errors.New("Z-report not found")
+	}
+	return report, nil
+}
+
+func (s *reportService) PrintDayReport(report *models.DayReport, paper receipt.Paper) []byte {
+	return s.renderer.DayReport(report, paper)
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gocats/internal/models"
+	"gocats/internal/repository"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ShiftRules sets whether checkouts and refunds need an open shift of the cashier
+type ShiftRules struct {
+	Required bool
+}
+
+type ShiftService interface {
+	OpenShift(openingFloat float64, note, actor string) (*models.Shift, error)
+	GetAllShifts(cashier, status string, opts repository.QueryOptions) ([]models.Shift, *repository.Page, error)
+	GetShiftByID(id uint) (*models.Shift, error)
+	GetCurrentShift(actor string) (*models.Shift, error)
+	RecordCashMovement(shiftID uint, movementType string, amount float64, reason, actor string, manage bool) (*models.CashMovement, error)
+	CloseShift(id uint, countedCash float64, note, actor string, manage bool) (*models.ShiftReport, error)
+	GetShiftReport(id uint) (*models.ShiftReport, error)
+}
+
+type shiftService struct {
+	db        *gorm.DB
+	shiftRepo repository.ShiftRepository
+}
+
+func NewShiftService(db *gorm.DB, shiftRepo repository.ShiftRepository) ShiftService {
+	return &shiftService{
+		db:        db,
+		shiftRepo: shiftRepo,
+	}
+}
+
+// OpenShift opens a shift for the actor with the cash float put in the drawer
+func (s *shiftService) OpenShift(openingFloat float64, note, actor string) (*models.Shift, error) {
+	if openingFloat < 0 {
+		return nil, errors.New("opening_float cannot be negative")
+	}
+	if actor = strings.TrimSpace(actor); actor == "" {
+		actor = "system"
+	}
+
+	if _, err := s.shiftRepo.FindOpen(actor); err == nil {
+		return nil, fmt.Errorf("cashier %s already has an open shift", actor)
+	}
+
+	shift := &models.Shift{
+		Cashier:      actor,
+		Status:       models.ShiftOpen,
+		OpeningFloat: roundMoney(openingFloat),
+		OpenedAt:     time.Now(),
+		Note:         note,
+	}
+	if err := s.shiftRepo.Create(s.db, shift); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+func (s *shiftService) GetAllShifts(cashier, status string, opts repository.QueryOptions) ([]models.Shift, *repository.Page, error) {
+	if status != "" && status != models.ShiftOpen && status != models.ShiftClosed {
+		return nil, nil, fmt.Errorf("status must be %s or %s", models.ShiftOpen, models.ShiftClosed)
+	}
+	return s.shiftRepo.FindAll(cashier, status, opts)
+}
+
+func (s *shiftService) GetShiftByID(id uint) (*models.Shift, error) {
+	shift, err := s.shiftRepo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("shift not found")
+	}
+	return shift, nil
+}
+
+// GetCurrentShift returns the open shift of the actor
+func (s *shiftService) GetCurrentShift(actor string) (*models.Shift, error) {
+	if actor = strings.TrimSpace(actor); actor == "" {
+		actor = "system"
+	}
+	open, err := s.shiftRepo.FindOpen(actor)
+	if err != nil {
+		return nil, fmt.Errorf("cashier %s has no open shift", actor)
+	}
+	return s.GetShiftByID(open.ID)
+}
+
+// RecordCashMovement records cash paid into or out of the drawer of an open
+// shift. Only the cashier of the shift may, unless manage is set.
+func (s *shiftService) RecordCashMovement(shiftID uint, movementType string, amount float64, reason, actor string, manage bool) (*models.CashMovement, error) {
+	if movementType != models.CashPayIn && movementType != models.CashPayOut {
+		return nil, fmt.Errorf("type must be %s or %s", models.CashPayIn, models.CashPayOut)
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("reason is required")
+	}
+	if actor = strings.TrimSpace(actor); actor == "" {
+		actor = "system"
+	}
+
+	movement := &models.CashMovement{
+		ShiftID:   shiftID,
+		Type:      movementType,
+		Amount:    roundMoney(amount),
+		Reason:    strings.TrimSpace(reason),
+		CreatedBy: actor,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		shift, err := s.shiftRepo.LockByID(tx, shiftID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("shift not found")
+			}
+			return err
+		}
+		if err := checkShiftCashier(shift, actor, manage); err != nil {
+			return err
+		}
+		if shift.Status != models.ShiftOpen {
+			return errors.New("shift is closed")
+		}
+		return s.shiftRepo.CreateMovement(tx, movement)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// CloseShift closes an open shift with the cash counted in the drawer and
+// stores the expected cash and variance. Only the cashier of the shift may,
+// unless manage is set.
+func (s *shiftService) CloseShift(id uint, countedCash float64, note, actor string, manage bool) (*models.ShiftReport, error) {
+	if countedCash < 0 {
+		return nil, errors.New("counted_cash cannot be negative")
+	}
+	if actor = strings.TrimSpace(actor); actor == "" {
+		actor = "system"
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// The lock waits for checkouts and refunds holding the shift to commit
+		shift, err := s.shiftRepo.LockByID(tx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("shift not found")
+			}
+			return err
+		}
+		if err := checkShiftCashier(shift, actor, manage); err != nil {
+			return err
+		}
+		if shift.Status != models.ShiftOpen {
+			return errors.New("shift is already closed")
+		}
+
+		report, err := s.shiftRepo.Totals(tx, shift.ID)
+		if err != nil {
+			return err
+		}
+		expected := expectedCash(shift, report)
+		counted := roundMoney(countedCash)
+		variance := roundMoney(counted - expected)
+		now := time.Now()
+
+		shift.Status = models.ShiftClosed
+		shift.ClosedAt = &now
+		shift.ClosedBy = actor
+		shift.ExpectedCash = &expected
+		shift.CountedCash = &counted
+		shift.Variance = &variance
+		if note != "" {
+			shift.Note = strings.TrimSpace(strings.Join([]string{shift.Note, note}, "\n"))
+		}
+		return s.shiftRepo.Update(tx, shift)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetShiftReport(id)
+}
+
+// checkShiftCashier rejects an actor who is not the cashier of the shift
+// unless they may manage the shifts of others
+func checkShiftCashier(shift *models.Shift, actor string, manage bool) error {
+	if !manage && shift.Cashier != actor {
+		return errors.New("shift belongs to another cashier")
+	}
+	return nil
+}
+
+// GetShiftReport reconciles the cash of a shift. Open shifts report the cash
+// expected so far.
+func (s *shiftService) GetShiftReport(id uint) (*models.ShiftReport, error) {
+	shift, err := s.GetShiftByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.shiftRepo.Totals(s.db, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	report.Shift = *shift
+	report.OpeningFloat = shift.OpeningFloat
+	report.ExpectedCash = expectedCash(shift, report)
+	if shift.ExpectedCash != nil {
+		report.ExpectedCash = *shift.ExpectedCash
+	}
+	report.CountedCash = shift.CountedCash
+	report.Variance = shift.Variance
+	if report.Payments == nil {
+		report.Payments = []models.PaymentMethodTotal{}
+	}
+	return report, nil
+}
+
+// expectedCash is the cash the drawer of a shift should hold
+func expectedCash(shift *models.Shift, totals *models.ShiftReport) float64 {
+	return roundMoney(shift.OpeningFloat + totals.CashSales - totals.CashRefunds + totals.PayIns - totals.PayOuts)
+}
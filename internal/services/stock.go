@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gocats/internal/models"
+	"gocats/internal/repository"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// stockDemand adds up the stock a checkout takes from each product and
+// variant across all its lines, so a bundle sold with one of its components,
+// or two bundles sharing a component, are checked against the combined quantity
+type stockDemand struct {
+	products map[uint]*stockNeed
+	variants map[uint]*stockNeed
+}
+
+// stockNeed is the quantity required of a product or variant and the stock
+// available when it was loaded
+type stockNeed struct {
+	name      string
+	available int
+	required  int
+}
+
+func newStockDemand() *stockDemand {
+	return &stockDemand{products: map[uint]*stockNeed{}, variants: map[uint]*stockNeed{}}
+}
+
+func (d *stockDemand) addProduct(product models.Product, quantity int) {
+	addNeed(d.products, product.ID, product.Name, product.Stock, quantity)
+}
+
+func (d *stockDemand) addVariant(variant models.ProductVariant, quantity int) {
+	addNeed(d.variants, variant.ID, variant.SKU, variant.Stock, quantity)
+}
+
+func addNeed(needs map[uint]*stockNeed, id uint, name string, available, quantity int) {
+	need, ok := needs[id]
+	if !ok {
+		need = &stockNeed{name: name, available: available}
+		needs[id] = need
+	}
+	need.required += quantity
+}
+
+// check returns an error for the first product or variant without enough stock
+func (d *stockDemand) check() error {
+	for _, id := range sortedIDs(d.products) {
+		if need := d.products[id]; need.available < need.required {
+			return fmt.Errorf("insufficient stock for product %s. Available: %d, Requested: %d",
+				need.name, need.available, need.required)
+		}
+	}
+	for _, id := range sortedIDs(d.variants) {
+		if need := d.variants[id]; need.available < need.required {
+			return fmt.Errorf("insufficient stock for variant %s. Available: %d, Requested: %d",
+				need.name, need.available, need.required)
+		}
+	}
+	return nil
+}
+
+// take decrements the stock, products then variants in ID order so concurrent
+// checkouts lock the rows in the same order. Stock sold by another checkout
+// since it was loaded fails the checkout.
+func (d *stockDemand) take(tx *gorm.DB, repo repository.TransactionRepository) error {
+	for _, id := range sortedIDs(d.products) {
+		need := d.products[id]
+		if err := repo.UpdateProductStock(tx, id, need.required); err != nil {
+			if errors.Is(err, repository.ErrInsufficientStock) {
+				return fmt.Errorf("insufficient stock for product %s", need.name)
+			}
+			return fmt.Errorf("failed to update product stock: %w", err)
+		}
+	}
+	for _, id := range sortedIDs(d.variants) {
+		need := d.variants[id]
+		if err := repo.UpdateVariantStock(tx, id, need.required); err != nil {
+			if errors.Is(err, repository.ErrInsufficientStock) {
+				return fmt.Errorf("insufficient stock for variant %s", need.name)
+			}
+			return fmt.Errorf("failed to update variant stock: %w", err)
+		}
+	}
+	return nil
+}
+
+func sortedIDs(needs map[uint]*stockNeed) []uint {
+	ids := make([]uint, 0, len(needs))
+	for id := range needs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"gocats/internal/models"
+	"gocats/internal/repository"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// giftCardAlphabet leaves out characters that are easily misread, like 0/O and 1/I
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var giftCardCodePattern = regexp.MustCompile(`^[A-Z0-9-]{4,32}$`)
+
+type StoredValueService interface {
+	IssueGiftCard(code string, amount float64, expiresAt *time.Time, actor string) (*models.StoredValueAccount, error)
+	GetGiftCard(code string) (*models.StoredValueAccount, error)
+	GetStoreCredit(customerID uint) (*models.StoredValueAccount, error)
+	AdjustStoreCredit(customerID uint, amount float64, note, actor string) (*models.StoredValueAccount, error)
+}
+
+type storedValueService struct {
+	db           *gorm.DB
+	valueRepo    repository.StoredValueRepository
+	customerRepo repository.CustomerRepository
+}
+
+func NewStoredValueService(db *gorm.DB, valueRepo repository.StoredValueRepository, customerRepo repository.CustomerRepository) StoredValueService {
+	return &storedValueService{
+		db:           db,
+		valueRepo:    valueRepo,
+		customerRepo: customerRepo,
+	}
+}
+
+// IssueGiftCard creates a gift card with the given balance. An empty code
+// generates one like ABCD-EFGH-JKLM-NPQR.
+func (s *storedValueService) IssueGiftCard(code string, amount float64, expiresAt *time.Time, actor string) (*models.StoredValueAccount, error) {
+	if amount <= 0 {
+		return nil, errors.New("gift card amount must be greater than zero")
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+	if actor = strings.TrimSpace(actor); actor == "" {
+		actor = "system"
+	}
+
+	code = normalizeGiftCardCode(code)
+	if code == "" {
+		generated, err := generateGiftCardCode()
+		if err != nil {
+			return nil, err
+		}
+		code = generated
+	} else if !giftCardCodePattern.MatchString(code) {
+		return nil, errors.New("gift card code must be 4 to 32 letters, digits or dashes")
+	}
+
+	if _, err := s.valueRepo.FindByCode(code); err == nil {
+		return nil, fmt.Errorf("gift card %s already exists", code)
+	}
+
+	account := &models.StoredValueAccount{
+		Type:      models.AccountGiftCard,
+		Code:      &code,
+		ExpiresAt: expiresAt,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.valueRepo.CreateAccount(tx, account); err != nil {
+			return err
+		}
+		return s.valueRepo.Post(tx, account, &models.StoredValueEntry{
+			Type:      models.ValueIssue,
+			Amount:    roundMoney(amount),
+			CreatedBy: actor,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetGiftCard(code)
+}
+
+// GetGiftCard returns a gift card with its balance and ledger
+func (s *storedValueService) GetGiftCard(code string) (*models.StoredValueAccount, error) {
+	account, err := s.valueRepo.FindByCode(normalizeGiftCardCode(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("gift card not found")
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+// GetStoreCredit returns the store credit of a customer with its ledger. A
+// customer who never had store credit has a zero balance.
+func (s *storedValueService) GetStoreCredit(customerID uint) (*models.StoredValueAccount, error) {
+	if _, err := s.customerRepo.FindByID(customerID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("customer not found")
+		}
+		return nil, err
+	}
+
+	account, err := s.valueRepo.FindByCustomerID(customerID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.StoredValueAccount{Type: models.AccountStoreCredit, CustomerID: &customerID}, nil
+	}
+	return account, err
+}
+
+// AdjustStoreCredit adds a positive amount to the store credit of a customer
+// or takes a negative one off, e.g. to correct a mistake
+func (s *storedValueService) AdjustStoreCredit(customerID uint, amount float64, note, actor string) (*models.StoredValueAccount, error) {
+	if amount = roundMoney(amount); amount == 0 {
+		return nil, errors.New("amount cannot be zero")
+	}
+	if actor = strings.TrimSpace(actor); actor == "" {
+		actor = "system"
+	}
+	if _, err := s.customerRepo.FindByID(customerID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("customer not found")
+		}
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		account, err := s.valueRepo.LockStoreCredit(tx, customerID)
+		if err != nil {
+			return err
+		}
+		err = s.valueRepo.Post(tx, account, &models.StoredValueEntry{
+			Type:      models.ValueAdjustment,
+			Amount:    amount,
+			Note:      note,
+			CreatedBy: actor,
+		})
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return fmt.Errorf("insufficient store credit. Available: %.2f", account.Balance)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetStoreCredit(customerID)
+}
+
+func normalizeGiftCardCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// generateGiftCardCode returns a random code of four groups of four characters
+func generateGiftCardCode() (string, error) {
+	var code strings.Builder
+	max := big.NewInt(int64(len(giftCardAlphabet)))
+	for i := 0; i < 16; i++ {
+		if i > 0 && i%4 == 0 {
+			code.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(giftCardAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
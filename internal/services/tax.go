@@ -0,0 +1,14 @@
+package services
+
+// TaxRules sets the tax rate in percent included in product prices
+type TaxRules struct {
+	Rate float64
+}
+
+// included returns the tax part of an amount that includes tax
+func (r TaxRules) included(amount float64) float64 {
+	if r.Rate <= 0 {
+		return 0
+	}
+	return roundMoney(amount * r.Rate / (100 + r.Rate))
+}
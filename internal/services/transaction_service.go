@@ -5,95 +5,203 @@ import (
 	"fmt"
 	"gocats/internal/models"
 	"gocats/internal/repository"
+	"sort"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TransactionService interface {
-	Checkout(request models.CheckoutRequest) (*models.Transaction, error)
+	Checkout(request models.CheckoutRequest, actor string) (*models.Transaction, error)
+	PreviewCheckout(request models.CheckoutRequest) (*models.CheckoutPreview, error)
+	RefundTransaction(id uint, reason string, toStoreCredit bool, actor string) (*models.Transaction, error)
+	GetAllTransactions(filter repository.TransactionFilter, opts repository.QueryOptions) ([]models.Transaction, *repository.Page, error)
 	GetTransactionByID(id uint) (*models.Transaction, error)
 	GetTodaySalesSummary() (*models.SalesSummary, error)
 	GetSalesSummaryByDateRange(startDate, endDate string) (*models.SalesSummary, error)
+	GetBundleSales(startDate, endDate string) ([]models.BundleSales, error)
+	GetComponentSales(startDate, endDate string) ([]models.ComponentSales, error)
+	GetDepartmentSales(startDate, endDate string) ([]models.DepartmentSales, error)
 }
 
 type transactionService struct {
-	db          *gorm.DB
-	transRepo   repository.TransactionRepository
-	productRepo repository.ProductRepository
+	db            *gorm.DB
+	transRepo     repository.TransactionRepository
+	productRepo   repository.ProductRepository
+	categoryRepo  repository.CategoryRepository
+	priceListRepo repository.PriceListRepository
+	promotionRepo repository.PromotionRepository
+	groupRepo     repository.CustomerGroupRepository
+	loyaltyRepo   repository.LoyaltyRepository
+	valueRepo     repository.StoredValueRepository
+	shiftRepo     repository.ShiftRepository
+	loyalty       LoyaltyRules
+	tax           TaxRules
+	invoice       InvoiceRules
+	shifts        ShiftRules
 }
 
 func NewTransactionService(
 	db *gorm.DB,
 	transRepo repository.TransactionRepository,
-	productRepo repository.ProductRepository) TransactionService {
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	priceListRepo repository.PriceListRepository,
+	promotionRepo repository.PromotionRepository,
+	groupRepo repository.CustomerGroupRepository,
+	loyaltyRepo repository.LoyaltyRepository,
+	valueRepo repository.StoredValueRepository,
+	shiftRepo repository.ShiftRepository,
+	loyalty LoyaltyRules,
+	tax TaxRules,
+	invoice InvoiceRules,
+	shifts ShiftRules) TransactionService {
 	return &transactionService{
-		db:          db,
-		transRepo:   transRepo,
-		productRepo: productRepo,
+		db:            db,
+		transRepo:     transRepo,
+		productRepo:   productRepo,
+		categoryRepo:  categoryRepo,
+		priceListRepo: priceListRepo,
+		promotionRepo: promotionRepo,
+		groupRepo:     groupRepo,
+		loyaltyRepo:   loyaltyRepo,
+		valueRepo:     valueRepo,
+		shiftRepo:     shiftRepo,
+		loyalty:       loyalty,
+		tax:           tax,
+		invoice:       invoice,
+		shifts:        shifts,
 	}
 }
 
-func (s *transactionService) Checkout(request models.CheckoutRequest) (*models.Transaction, error) {
+func (s *transactionService) Checkout(request models.CheckoutRequest, actor string) (*models.Transaction, error) {
+	if actor = strings.TrimSpace(actor); actor == "" {
+		actor = "system"
+	}
 	if len(request.Items) == 0 {
 		return nil, errors.New("checkout items cannot be empty")
 	}
+	if request.RedeemPoints < 0 {
+		return nil, errors.New("redeem_points cannot be negative")
+	}
+	if request.RedeemPoints > 0 && request.CustomerID == nil {
+		return nil, errors.New("customer_id is required to redeem points")
+	}
+	tenders, err := checkoutTenders(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lines are priced with the price lists and promotions active at checkout
+	// time and the price tiers of the customer group
+	now := time.Now()
+	rules, engine, err := s.loadPricing(now, request.CustomerID)
+	if err != nil {
+		return nil, err
+	}
 
 	var transaction *models.Transaction
 	var transactionDetails []models.TransactionDetail
 
 	// Start database transaction
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the customer so concurrent checkouts cannot spend the same points
+		if request.CustomerID != nil {
+			var customer models.Customer
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, *request.CustomerID).Error; err != nil {
+				return fmt.Errorf("customer ID %d not found", *request.CustomerID)
+			}
+		}
+
+		// Validate all items and price the lines using tx context
+		var err error
+		var demand *stockDemand
+		transactionDetails, demand, err = s.priceLines(tx, request.Items, rules, engine)
+		if err != nil {
+			return err
+		}
+
 		var totalAmount float64
+		for _, detail := range transactionDetails {
+			totalAmount += detail.Subtotal
+		}
 
-		// Validate all items and calculate total
-		for _, item := range request.Items {
-			if item.Quantity <= 0 {
-				return fmt.Errorf("invalid quantity for product ID %d", item.ProductID)
-			}
+		// Create transaction
+		transaction = &models.Transaction{
+			TotalAmount: roundMoney(totalAmount),
+			CustomerID:  request.CustomerID,
+			CreatedAt:   now,
+		}
+		if transaction.ShiftID, err = s.openShiftID(tx, actor); err != nil {
+			return err
+		}
 
-			// Get product and check stock using tx context
-			var product models.Product
-			if err := tx.First(&product, item.ProductID).Error; err != nil {
-				return fmt.Errorf("product ID %d not found", item.ProductID)
+		if request.RedeemPoints > 0 {
+			balance, err := s.loyaltyRepo.GetBalance(tx, *request.CustomerID)
+			if err != nil {
+				return err
 			}
-
-			if product.Stock < item.Quantity {
-				return fmt.Errorf("insufficient stock for product %s. Available: %d, Requested: %d",
-					product.Name, product.Stock, item.Quantity)
+			if balance < request.RedeemPoints {
+				return fmt.Errorf("insufficient loyalty points. Available: %d, Requested: %d", balance, request.RedeemPoints)
 			}
+			transaction.PointsRedeemed = request.RedeemPoints
+			transaction.PointsAmount = roundMoney(float64(request.RedeemPoints) * s.loyalty.PointValue)
+			if transaction.PointsAmount > transaction.TotalAmount {
+				return errors.New("redeemed points exceed the transaction total")
+			}
+		}
+		if request.CustomerID != nil {
+			transaction.PointsEarned = s.loyalty.earnedPoints(transaction.AmountDue())
+		}
 
-			subtotal := product.Price * float64(item.Quantity)
-			totalAmount += subtotal
+		for _, tender := range tenders {
+			transaction.TenderedAmount += tender.Amount
+		}
+		transaction.TenderedAmount = roundMoney(transaction.TenderedAmount)
+		if transaction.TenderedAmount > roundMoney(transaction.AmountDue()) {
+			return errors.New("tenders exceed the amount due")
+		}
 
-			// Store transaction detail for later creation
-			transactionDetails = append(transactionDetails, models.TransactionDetail{
-				ProductID: uint(item.ProductID),
-				Quantity:  item.Quantity,
-				Subtotal:  subtotal,
-			})
+		transaction.TaxAmount = s.tax.included(transaction.TotalAmount)
+		if err := settlePayment(transaction, request); err != nil {
+			return err
 		}
 
-		// Create transaction
-		transaction = &models.Transaction{
-			TotalAmount: totalAmount,
+		// Allocate the invoice number last, the sequence stays locked until commit
+		series := s.invoice.series(now)
+		sequence, err := s.transRepo.NextInvoiceSequence(tx, series)
+		if err != nil {
+			return fmt.Errorf("failed to allocate invoice number: %w", err)
 		}
+		invoiceNumber := s.invoice.number(series, sequence)
+		transaction.InvoiceNumber = &invoiceNumber
 
 		if err := s.transRepo.CreateTransaction(tx, transaction); err != nil {
 			return fmt.Errorf("failed to create transaction: %w", err)
 		}
 
-		// Create transaction details and update stock
+		if err := s.recordPoints(tx, transaction); err != nil {
+			return fmt.Errorf("failed to record loyalty points: %w", err)
+		}
+
+		if err := s.redeemTenders(tx, transaction, tenders); err != nil {
+			return err
+		}
+
+		// Create transaction details
 		for i := range transactionDetails {
 			transactionDetails[i].TransactionID = transaction.ID
 
 			if err := s.transRepo.CreateTransactionDetail(tx, &transactionDetails[i]); err != nil {
 				return fmt.Errorf("failed to create transaction detail: %w", err)
 			}
+		}
 
-			// Update product stock
-			if err := s.transRepo.UpdateProductStock(tx, transactionDetails[i].ProductID, transactionDetails[i].Quantity); err != nil {
-				return fmt.Errorf("failed to update product stock: %w", err)
-			}
+		// Update stock; bundles consume the stock of their components, not their own
+		if err := demand.take(tx, s.transRepo); err != nil {
+			return err
 		}
 
 		transaction.TransactionDetails = transactionDetails
@@ -107,6 +215,568 @@ func (s *transactionService) Checkout(request models.CheckoutRequest) (*models.T
 	return transaction, nil
 }
 
+// recordPoints writes the loyalty points redeemed and earned by a transaction to the ledger
+func (s *transactionService) recordPoints(tx *gorm.DB, transaction *models.Transaction) error {
+	if transaction.CustomerID == nil {
+		return nil
+	}
+
+	entries := []models.LoyaltyEntry{
+		{Type: models.LoyaltyRedeem, Points: -transaction.PointsRedeemed},
+		{Type: models.LoyaltyEarn, Points: transaction.PointsEarned},
+	}
+	for _, entry := range entries {
+		if entry.Points == 0 {
+			continue
+		}
+		entry.CustomerID = *transaction.CustomerID
+		entry.TransactionID = &transaction.ID
+		if err := s.loyaltyRepo.CreateEntry(tx, &entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settlePayment sets how the balance due of a transaction is paid. Cash may be
+// more than the balance due and the difference is given back as change; other
+// methods are charged the exact amount.
+func settlePayment(transaction *models.Transaction, request models.CheckoutRequest) error {
+	method := request.PaymentMethod
+	if method == "" {
+		method = models.PaymentCash
+	}
+	if !containsString(models.PaymentMethods, method) {
+		return fmt.Errorf("payment_method must be one of %s", strings.Join(models.PaymentMethods, ", "))
+	}
+	if request.AmountPaid < 0 {
+		return errors.New("amount_paid cannot be negative")
+	}
+
+	due := roundMoney(transaction.BalanceDue())
+	paid := roundMoney(request.AmountPaid)
+	transaction.PaymentMethod = method
+	transaction.AmountPaid = due
+	if paid == 0 || paid == due {
+		return nil
+	}
+	if method != models.PaymentCash {
+		return fmt.Errorf("amount_paid must equal the balance due of %.2f for %s payments", due, method)
+	}
+	if paid < due {
+		return fmt.Errorf("amount paid %.2f is less than the balance due of %.2f", paid, due)
+	}
+	transaction.AmountPaid = paid
+	transaction.Change = roundMoney(paid - due)
+	return nil
+}
+
+// checkoutTenders validates the tenders of a checkout request and returns them
+// in a fixed order, store credit first and gift cards by code, so concurrent
+// checkouts lock the accounts in the same order
+func checkoutTenders(request models.CheckoutRequest) ([]models.Tender, error) {
+	tenders := make([]models.Tender, len(request.Tenders))
+	seen := make(map[string]bool, len(request.Tenders))
+	for i, tender := range request.Tenders {
+		tender.Amount = roundMoney(tender.Amount)
+		if tender.Amount <= 0 {
+			return nil, fmt.Errorf("tender %d amount must be greater than zero", i+1)
+		}
+
+		switch tender.Type {
+		case models.AccountGiftCard:
+			tender.Code = normalizeGiftCardCode(tender.Code)
+			if tender.Code == "" {
+				return nil, fmt.Errorf("tender %d requires a gift card code", i+1)
+			}
+		case models.AccountStoreCredit:
+			if request.CustomerID == nil {
+				return nil, errors.New("customer_id is required to pay with store credit")
+			}
+			tender.Code = ""
+		default:
+			return nil, fmt.Errorf("tender %d type must be %q or %q", i+1, models.AccountGiftCard, models.AccountStoreCredit)
+		}
+
+		key := tender.Type + ":" + tender.Code
+		if seen[key] {
+			return nil, fmt.Errorf("tender %d repeats a gift card or store credit", i+1)
+		}
+		seen[key] = true
+		tenders[i] = tender
+	}
+
+	sort.Slice(tenders, func(i, j int) bool {
+		if tenders[i].Type != tenders[j].Type {
+			return tenders[i].Type == models.AccountStoreCredit
+		}
+		return tenders[i].Code < tenders[j].Code
+	})
+	return tenders, nil
+}
+
+// redeemTenders takes the tender amounts off their accounts. The account rows
+// stay locked until the checkout commits, so the same balance cannot be spent
+// twice by concurrent checkouts.
+func (s *transactionService) redeemTenders(tx *gorm.DB, transaction *models.Transaction, tenders []models.Tender) error {
+	for _, tender := range tenders {
+		var account *models.StoredValueAccount
+		var err error
+		name := "store credit"
+		if tender.Type == models.AccountGiftCard {
+			name = "gift card " + tender.Code
+			account, err = s.valueRepo.LockByCode(tx, tender.Code)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("gift card %s not found", tender.Code)
+			}
+		} else {
+			account, err = s.valueRepo.LockStoreCredit(tx, *transaction.CustomerID)
+		}
+		if err != nil {
+			return err
+		}
+		if account.ExpiredAt(time.Now()) {
+			return fmt.Errorf("%s has expired", name)
+		}
+
+		err = s.valueRepo.Post(tx, account, &models.StoredValueEntry{
+			TransactionID: &transaction.ID,
+			Type:          models.ValueRedeem,
+			Amount:        -tender.Amount,
+			CreatedBy:     "checkout",
+		})
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return fmt.Errorf("insufficient balance on %s. Available: %.2f, Requested: %.2f", name, account.Balance, tender.Amount)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to redeem %s: %w", name, err)
+		}
+
+		transactionTender := models.TransactionTender{
+			TransactionID: transaction.ID,
+			AccountID:     account.ID,
+			Type:          tender.Type,
+			Amount:        tender.Amount,
+		}
+		if err := s.valueRepo.CreateTender(tx, &transactionTender); err != nil {
+			return fmt.Errorf("failed to record tender: %w", err)
+		}
+		transaction.Tenders = append(transaction.Tenders, transactionTender)
+	}
+	return nil
+}
+
+// RefundTransaction refunds a transaction in full: its stock is returned, the
+// points it earned are taken back, the points redeemed are given back and gift
+// card and store credit tenders go back to their accounts. With toStoreCredit
+// the rest of the amount is credited to the customer's store credit instead of
+// being paid out.
+func (s *transactionService) RefundTransaction(id uint, reason string, toStoreCredit bool, actor string) (*models.Transaction, error) {
+	if actor = strings.TrimSpace(actor); actor == "" {
+		actor = "system"
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		transaction, err := s.transRepo.FindForUpdate(tx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("transaction not found")
+			}
+			return err
+		}
+		if transaction.Refund != nil {
+			return errors.New("transaction is already refunded")
+		}
+
+		// Negative quantities put the stock back
+		for _, detail := range transaction.TransactionDetails {
+			if len(detail.BundleComponents) > 0 {
+				for _, component := range detail.BundleComponents {
+					if err := s.transRepo.UpdateProductStock(tx, component.ProductID, -component.Quantity); err != nil {
+						return fmt.Errorf("failed to restock component: %w", err)
+					}
+				}
+				continue
+			}
+			if detail.VariantID != nil {
+				if err := s.transRepo.UpdateVariantStock(tx, *detail.VariantID, -detail.Quantity); err != nil {
+					return fmt.Errorf("failed to restock variant: %w", err)
+				}
+				continue
+			}
+			if err := s.transRepo.UpdateProductStock(tx, detail.ProductID, -detail.Quantity); err != nil {
+				return fmt.Errorf("failed to restock product: %w", err)
+			}
+		}
+
+		if err := s.reversePoints(tx, transaction); err != nil {
+			return fmt.Errorf("failed to reverse loyalty points: %w", err)
+		}
+
+		refund := &models.Refund{
+			TransactionID: transaction.ID,
+			Amount:        roundMoney(transaction.AmountDue()),
+			Reason:        reason,
+			RefundedBy:    actor,
+		}
+		if refund.ShiftID, err = s.openShiftID(tx, actor); err != nil {
+			return err
+		}
+		if err := s.refundTenders(tx, transaction, actor); err != nil {
+			return fmt.Errorf("failed to refund tenders: %w", err)
+		}
+		if toStoreCredit {
+			if transaction.CustomerID == nil {
+				return errors.New("only transactions of a customer can be refunded to store credit")
+			}
+			refund.StoreCredit = roundMoney(transaction.BalanceDue())
+			if err := s.creditStoreCredit(tx, transaction, refund.StoreCredit, actor); err != nil {
+				return fmt.Errorf("failed to credit store credit: %w", err)
+			}
+		} else if transaction.PaymentMethod == models.PaymentCash {
+			// Cash sales are paid back from the drawer
+			refund.CashAmount = roundMoney(transaction.BalanceDue())
+		}
+		return s.transRepo.CreateRefund(tx, refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTransactionByID(id)
+}
+
+// openShiftID returns the open shift of the cashier, share-locked until tx ends
+// so it cannot close in the meantime, or nil when the cashier has none and
+// shifts are not required
+func (s *transactionService) openShiftID(tx *gorm.DB, cashier string) (*uint, error) {
+	shift, err := s.shiftRepo.LockOpen(tx, cashier)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if s.shifts.Required {
+			return nil, fmt.Errorf("cashier %s has no open shift", cashier)
+		}
+		return nil, nil
+	}
+	return &shift.ID, nil
+}
+
+// refundTenders puts the gift card and store credit amounts of a refunded
+// transaction back on their accounts
+func (s *transactionService) refundTenders(tx *gorm.DB, transaction *models.Transaction, actor string) error {
+	for _, tender := range transaction.Tenders {
+		account, err := s.valueRepo.LockByID(tx, tender.AccountID)
+		if err != nil {
+			return err
+		}
+		err = s.valueRepo.Post(tx, account, &models.StoredValueEntry{
+			TransactionID: &transaction.ID,
+			Type:          models.ValueRefund,
+			Amount:        tender.Amount,
+			CreatedBy:     actor,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// creditStoreCredit adds the refunded amount of a transaction to the store
+// credit of its customer
+func (s *transactionService) creditStoreCredit(tx *gorm.DB, transaction *models.Transaction, amount float64, actor string) error {
+	if amount <= 0 {
+		return nil
+	}
+	account, err := s.valueRepo.LockStoreCredit(tx, *transaction.CustomerID)
+	if err != nil {
+		return err
+	}
+	return s.valueRepo.Post(tx, account, &models.StoredValueEntry{
+		TransactionID: &transaction.ID,
+		Type:          models.ValueRefund,
+		Amount:        amount,
+		CreatedBy:     actor,
+	})
+}
+
+// reversePoints cancels the ledger entries of a refunded transaction
+func (s *transactionService) reversePoints(tx *gorm.DB, transaction *models.Transaction) error {
+	if transaction.CustomerID == nil {
+		return nil
+	}
+
+	var customer models.Customer
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, *transaction.CustomerID).Error; err != nil {
+		return err
+	}
+
+	entries, err := s.loyaltyRepo.FindByTransactionID(tx, transaction.ID)
+	if err != nil {
+		return err
+	}
+	balance, err := s.loyaltyRepo.GetBalance(tx, customer.ID)
+	if err != nil {
+		return err
+	}
+
+	// Redeemed points are given back first. Earned points the customer already
+	// spent cannot be taken back, so the balance never goes negative.
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Points < entries[j].Points })
+	for _, entry := range entries {
+		points := -entry.Points
+		if points < 0 {
+			points = -min(-points, max(balance, 0))
+		}
+		if points == 0 {
+			continue
+		}
+		balance += points
+
+		reversal := models.LoyaltyEntry{
+			CustomerID:    entry.CustomerID,
+			TransactionID: entry.TransactionID,
+			Type:          models.LoyaltyReversal,
+			Points:        points,
+		}
+		if err := s.loyaltyRepo.CreateEntry(tx, &reversal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PreviewCheckout prices a checkout request with the current price lists,
+// promotions and customer group tiers without creating a transaction or
+// changing stock
+func (s *transactionService) PreviewCheckout(request models.CheckoutRequest) (*models.CheckoutPreview, error) {
+	if len(request.Items) == 0 {
+		return nil, errors.New("checkout items cannot be empty")
+	}
+
+	rules, engine, err := s.loadPricing(time.Now(), request.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	details, _, err := s.priceLines(s.db, request.Items, rules, engine)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[uint]string, len(engine.promotions))
+	for _, promotion := range engine.promotions {
+		names[promotion.ID] = promotion.Name
+	}
+
+	preview := &models.CheckoutPreview{Lines: make([]models.PreviewLine, len(details))}
+	for i, detail := range details {
+		line := models.PreviewLine{
+			ProductID:   detail.ProductID,
+			VariantID:   detail.VariantID,
+			Quantity:    detail.Quantity,
+			UnitPrice:   detail.UnitPrice,
+			Discount:    detail.Discount,
+			Subtotal:    detail.Subtotal,
+			PriceListID: detail.PriceListID,
+			PriceTierID: detail.PriceTierID,
+			PromotionID: detail.PromotionID,
+		}
+		if detail.PromotionID != nil {
+			line.PromotionName = names[*detail.PromotionID]
+		}
+		preview.Lines[i] = line
+		preview.Subtotal += detail.UnitPrice * float64(detail.Quantity)
+		preview.Discount += detail.Discount
+		preview.TotalAmount += detail.Subtotal
+	}
+	preview.Subtotal = roundMoney(preview.Subtotal)
+	preview.Discount = roundMoney(preview.Discount)
+	preview.TotalAmount = roundMoney(preview.TotalAmount)
+	preview.TaxAmount = s.tax.included(preview.TotalAmount)
+
+	return preview, nil
+}
+
+// loadPricing loads the price lists and promotions active at the given time
+// and the price tiers of the customer's group, if any
+func (s *transactionService) loadPricing(at time.Time, customerID *uint) (*priceRules, *promotionEngine, error) {
+	rules, err := loadPriceRules(s.priceListRepo, s.categoryRepo, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	if customerID != nil {
+		tiers, err := s.groupRepo.FindTiersByCustomerID(*customerID)
+		if err != nil {
+			return nil, nil, err
+		}
+		rules.withTiers(tiers)
+	}
+	engine, err := loadPromotionEngine(s.promotionRepo, s.categoryRepo, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rules, engine, nil
+}
+
+// priceLines validates the checkout items against stock, prices them with
+// the price lists and customer group tiers and applies the promotions. The
+// returned demand is the stock the lines take, checked across all lines.
+func (s *transactionService) priceLines(tx *gorm.DB, items []models.CheckoutItem, rules *priceRules, engine *promotionEngine) ([]models.TransactionDetail, *stockDemand, error) {
+	lines := make([]checkoutLine, 0, len(items))
+	demand := newStockDemand()
+
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, nil, fmt.Errorf("invalid quantity for product ID %d", item.ProductID)
+		}
+
+		if item.VariantID != 0 {
+			line, err := s.variantLine(tx, item, rules, demand)
+			if err != nil {
+				return nil, nil, err
+			}
+			lines = append(lines, *line)
+			continue
+		}
+
+		// Get product and check stock
+		var product models.Product
+		if err := tx.Preload("Variants").Preload("BundleItems.Component").First(&product, item.ProductID).Error; err != nil {
+			return nil, nil, fmt.Errorf("product ID %d not found", item.ProductID)
+		}
+
+		if product.HasVariants() {
+			return nil, nil, fmt.Errorf("product %s has variants, variant_id is required", product.Name)
+		}
+
+		if product.IsBundle {
+			line, err := bundleLine(product, item.Quantity, rules, demand)
+			if err != nil {
+				return nil, nil, err
+			}
+			lines = append(lines, *line)
+			continue
+		}
+
+		demand.addProduct(product, item.Quantity)
+
+		price, priceList := rules.price(product, product.Price, false)
+		lines = append(lines, checkoutLine{
+			detail: &models.TransactionDetail{
+				ProductID:   uint(item.ProductID),
+				Quantity:    item.Quantity,
+				UnitPrice:   price,
+				Subtotal:    price * float64(item.Quantity),
+				PriceListID: priceListID(priceList),
+			},
+			product: product,
+			regular: product.Price,
+		})
+	}
+
+	if err := demand.check(); err != nil {
+		return nil, nil, err
+	}
+
+	rules.applyTiers(lines)
+	engine.apply(lines)
+
+	details := make([]models.TransactionDetail, len(lines))
+	for i, line := range lines {
+		details[i] = *line.detail
+	}
+	return details, demand, nil
+}
+
+// bundleLine adds the component stock a bundle product takes to demand and
+// records the component quantities the line consumes
+func bundleLine(bundle models.Product, quantity int, rules *priceRules, demand *stockDemand) (*checkoutLine, error) {
+	if len(bundle.BundleItems) == 0 {
+		return nil, fmt.Errorf("bundle %s has no components", bundle.Name)
+	}
+
+	components := make([]models.TransactionBundleComponent, len(bundle.BundleItems))
+	for i, item := range bundle.BundleItems {
+		// Archived components are not loaded
+		if item.Component.ID == 0 {
+			return nil, fmt.Errorf("a component of bundle %s is archived", bundle.Name)
+		}
+
+		required := item.Quantity * quantity
+		demand.addProduct(item.Component, required)
+		components[i] = models.TransactionBundleComponent{
+			ProductID: item.ComponentID,
+			Quantity:  required,
+		}
+	}
+
+	price, priceList := rules.price(bundle, bundle.Price, false)
+	return &checkoutLine{
+		detail: &models.TransactionDetail{
+			ProductID:        bundle.ID,
+			Quantity:         quantity,
+			UnitPrice:        price,
+			Subtotal:         price * float64(quantity),
+			PriceListID:      priceListID(priceList),
+			BundleComponents: components,
+		},
+		product: bundle,
+		regular: bundle.Price,
+	}, nil
+}
+
+// variantLine validates a checkout item that references a product variant and
+// prices it with the variant override or the parent product price
+func (s *transactionService) variantLine(tx *gorm.DB, item models.CheckoutItem, rules *priceRules, demand *stockDemand) (*checkoutLine, error) {
+	var variant models.ProductVariant
+	if err := tx.Preload("Product").First(&variant, item.VariantID).Error; err != nil {
+		return nil, fmt.Errorf("variant ID %d not found", item.VariantID)
+	}
+
+	// The parent product is not loaded when it has been archived
+	if variant.Product == nil {
+		return nil, fmt.Errorf("product of variant ID %d not found", item.VariantID)
+	}
+
+	if item.ProductID != 0 && variant.ProductID != uint(item.ProductID) {
+		return nil, fmt.Errorf("variant ID %d does not belong to product ID %d", item.VariantID, item.ProductID)
+	}
+
+	demand.addVariant(variant, item.Quantity)
+
+	variantID := variant.ID
+	regular := variant.EffectivePrice(variant.Product.Price)
+	price, priceList := rules.price(*variant.Product, regular, variant.Price != nil)
+	return &checkoutLine{
+		detail: &models.TransactionDetail{
+			ProductID:   variant.ProductID,
+			VariantID:   &variantID,
+			Quantity:    item.Quantity,
+			UnitPrice:   price,
+			Subtotal:    price * float64(item.Quantity),
+			PriceListID: priceListID(priceList),
+		},
+		product:  *variant.Product,
+		regular:  regular,
+		ownPrice: variant.Price != nil,
+	}, nil
+}
+
+func priceListID(ref *models.PriceListRef) *uint {
+	if ref == nil {
+		return nil
+	}
+	id := ref.ID
+	return &id
+}
+
+func (s *transactionService) GetAllTransactions(filter repository.TransactionFilter, opts repository.QueryOptions) ([]models.Transaction, *repository.Page, error) {
+	return s.transRepo.FindAll(filter, opts)
+}
+
 func (s *transactionService) GetTransactionByID(id uint) (*models.Transaction, error) {
 	transaction, err := s.transRepo.FindByID(id)
 	if err != nil {
@@ -134,3 +804,27 @@ func (s *transactionService) GetSalesSummaryByDateRange(startDate, endDate strin
 	}
 	return summary, nil
 }
+
+func (s *transactionService) GetBundleSales(startDate, endDate string) ([]models.BundleSales, error) {
+	if startDate == "" || endDate == "" {
+		return nil, errors.New("start_date and end_date are required")
+	}
+
+	return s.transRepo.GetBundleSales(startDate, endDate)
+}
+
+func (s *transactionService) GetComponentSales(startDate, endDate string) ([]models.ComponentSales, error) {
+	if startDate == "" || endDate == "" {
+		return nil, errors.New("start_date and end_date are required")
+	}
+
+	return s.transRepo.GetComponentSales(startDate, endDate)
+}
+
+func (s *transactionService) GetDepartmentSales(startDate, endDate string) ([]models.DepartmentSales, error) {
+	if startDate == "" || endDate == "" {
+		return nil, errors.New("start_date and end_date are required")
+	}
+
+	return s.transRepo.GetDepartmentSales(startDate, endDate)
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gocats/internal/auth"
+	"gocats/internal/models"
+	"gocats/internal/repository"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// usernamePattern accepts lowercase letters, digits, dots, dashes and underscores
+var usernamePattern = regexp.MustCompile(`^[a-z0-9._-]{3,100}$`)
+
+// errInvalidLogin is returned for both unknown users and wrong passwords
+var errInvalidLogin = errors.New("invalid username or password")
+
+type UserService interface {
+	Login(username, password string) (*models.LoginResult, error)
+	Verify(token string) (*auth.Identity, error)
+	CreateUser(username, name, password, role string) (*models.User, error)
+	GetAllUsers(role string, opts repository.QueryOptions) ([]models.User, *repository.Page, error)
+	GetUserByID(id uint) (*models.User, error)
+	UpdateUser(id uint, name, role, password string, active *bool) (*models.User, error)
+	DeleteUser(id uint) error
+	EnsureAdmin(username, password string) error
+}
+
+type userService struct {
+	repo   repository.UserRepository
+	tokens *auth.Tokens
+	// dummyHash is compared against when the username is unknown, so both
+	// failed logins take as long
+	dummyHash string
+}
+
+func NewUserService(repo repository.UserRepository, tokens *auth.Tokens) UserService {
+	dummyHash, err := auth.HashPassword("gocats-unknown-user")
+	if err != nil {
+		log.Fatalf("Error hashing password: %v", err)
+	}
+	return &userService{repo: repo, tokens: tokens, dummyHash: dummyHash}
+}
+
+// Login checks the password of an active user and issues a bearer token
+func (s *userService) Login(username, password string) (*models.LoginResult, error) {
+	user, err := s.repo.FindByUsername(strings.ToLower(strings.TrimSpace(username)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			auth.CheckPassword(s.dummyHash, password)
+			return nil, errInvalidLogin
+		}
+		return nil, err
+	}
+	if !auth.CheckPassword(user.PasswordHash, password) || !user.Active || user.DeletedAt.Valid {
+		return nil, errInvalidLogin
+	}
+
+	token, expiresAt, err := s.tokens.Issue(user.ID, user.Username, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.repo.TouchLogin(user.ID, now); err != nil {
+		return nil, err
+	}
+	user.LastLoginAt = &now
+
+	return &models.LoginResult{Token: token, ExpiresAt: expiresAt, User: *user}, nil
+}
+
+// Verify checks a login token and returns the current username and role of its
+// user, so deactivated users and role changes take effect before the token expires
+func (s *userService) Verify(token string) (*auth.Identity, error) {
+	identity, err := s.tokens.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.FindByID(identity.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !user.Active {
+		return nil, errors.New("user is deactivated")
+	}
+
+	identity.Username = user.Username
+	identity.Role = user.Role
+	return identity, nil
+}
+
+func (s *userService) CreateUser(username, name, password, role string) (*models.User, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if !usernamePattern.MatchString(username) {
+		return nil, errors.New("username must be 3 to 100 lowercase letters, digits, dots, dashes or underscores")
+	}
+	if !auth.ValidRole(role) {
+		return nil, fmt.Errorf("role must be one of %s", strings.Join(models.Roles, ", "))
+	}
+	if _, err := s.repo.FindByUsername(username); err == nil {
+		return nil, fmt.Errorf("username %s already exists", username)
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:     username,
+		Name:         strings.TrimSpace(name),
+		PasswordHash: hash,
+		Role:         role,
+		Active:       true,
+	}
+	if err := s.repo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetAllUsers lists users, optionally only those with one role
+func (s *userService) GetAllUsers(role string, opts repository.QueryOptions) ([]models.User, *repository.Page, error) {
+	return s.repo.FindAll(strings.TrimSpace(role), opts)
+}
+
+func (s *userService) GetUserByID(id uint) (*models.User, error) {
+	if id == 0 {
+		return nil, errors.New("user ID cannot be zero")
+	}
+
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUser changes the name, role, password and active flag of a user. Empty
+// name, role and password and a nil active flag keep the current values.
+func (s *userService) UpdateUser(id uint, name, role, password string, active *bool) (*models.User, error) {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name = strings.TrimSpace(name); name != "" {
+		user.Name = name
+	}
+	if role != "" {
+		if !auth.ValidRole(role) {
+			return nil, fmt.Errorf("role must be one of %s", strings.Join(models.Roles, ", "))
+		}
+		user.Role = role
+	}
+	if active != nil {
+		user.Active = *active
+	}
+	if password != "" {
+		hash, err := auth.HashPassword(password)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
+	}
+
+	// Demoting or deactivating must leave another active admin
+	save := s.repo.Update
+	if user.Role != models.RoleAdmin || !user.Active {
+		save = s.repo.UpdateKeepingAdmin
+	}
+	if err := save(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *userService) DeleteUser(id uint) error {
+	if _, err := s.GetUserByID(id); err != nil {
+		return err
+	}
+	return s.repo.DeleteKeepingAdmin(id)
+}
+
+// EnsureAdmin creates the first admin when there are no users yet, so the API
+// can be signed in to on a fresh database
+func (s *userService) EnsureAdmin(username, password string) error {
+	count, err := s.repo.Count()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if username == "" || password == "" {
+		log.Printf("warning: no users exist, set ADMIN_USERNAME and ADMIN_PASSWORD to create the first admin")
+		return nil
+	}
+
+	if _, err := s.CreateUser(username, "Administrator", password, models.RoleAdmin); err != nil {
+		return err
+	}
+	log.Printf("Created admin user %s", username)
+	return nil
+}
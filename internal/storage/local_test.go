@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPutDelete(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStorage(dir, "/uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Put("products/1/a.jpg", strings.NewReader("jpeg"), 4, "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "products", "1", "a.jpg"))
+	if err != nil || string(data) != "jpeg" {
+		t.Fatalf("stored file = %q, %v", data, err)
+	}
+	if got := s.URL("products/1/a.jpg"); got != "/uploads/products/1/a.jpg" {
+		t.Errorf("URL = %q", got)
+	}
+
+	if err := s.Delete("products/1/a.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "products", "1", "a.jpg")); !os.IsNotExist(err) {
+		t.Errorf("file still exists after Delete: %v", err)
+	}
+	if err := s.Delete("products/1/a.jpg"); err != nil {
+		t.Errorf("Delete of a missing file: %v", err)
+	}
+}
+
+func TestLocalPathStaysInBaseDir(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStorage(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"../../etc/passwd", "/etc/passwd", "a/../../b"} {
+		path, err := s.path(key)
+		if err != nil {
+			t.Errorf("path(%q): %v", key, err)
+			continue
+		}
+		if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			t.Errorf("path(%q) = %q, outside %q", key, path, dir)
+		}
+	}
+	if _, err := s.path(".."); err == nil {
+		t.Error("path(..) resolved to the base directory")
+	}
+}
+
+func TestLocalHandlerDoesNotListDirectories(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStorage(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("products/1/a.jpg", strings.NewReader("jpeg"), 4, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.StripPrefix("/uploads/", s.Handler()))
+	defer server.Close()
+
+	for path, want := range map[string]int{
+		"/uploads/products/1/a.jpg": http.StatusOK,
+		"/uploads/products/1/":      http.StatusNotFound,
+		"/uploads/products":         http.StatusNotFound,
+		"/uploads/":                 http.StatusNotFound,
+		"/uploads/missing.jpg":      http.StatusNotFound,
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s = %d, want %d", path, resp.StatusCode, want)
+		}
+		if want == http.StatusOK && string(body) != "jpeg" {
+			t.Errorf("GET %s body = %q", path, body)
+		}
+	}
+}
+
+func TestLocalRoute(t *testing.T) {
+	for publicURL, want := range map[string]string{
+		"":                                "/uploads/",
+		"/uploads/":                       "/uploads/",
+		"/media/products":                 "/media/products/",
+		"https://shop.example.com/files/": "/files/",
+		"https://cdn.example.com":         "",
+	} {
+		s, err := NewLocalStorage(t.TempDir(), publicURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Route(); got != want {
+			t.Errorf("Route() with public URL %q = %q, want %q", publicURL, got, want)
+		}
+	}
+}
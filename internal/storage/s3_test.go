@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordedRequest is a request received by the S3 stand-in
+type recordedRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+// newS3StandIn starts a server answering S3 object requests with status and
+// recording what it receives
+func newS3StandIn(t *testing.T, status int) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, recordedRequest{method: r.Method, path: r.URL.EscapedPath(), header: r.Header.Clone(), body: string(body)})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestS3(t *testing.T, endpoint string) *S3Storage {
+	t.Helper()
+	s, err := NewS3Storage(S3Config{
+		Endpoint:  endpoint,
+		Bucket:    "images",
+		Region:    "us-east-1",
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return s
+}
+
+func TestS3PutSignsRequest(t *testing.T) {
+	server, requests := newS3StandIn(t, http.StatusOK)
+	s := newTestS3(t, server.URL)
+
+	if err := s.Put("products/1/a b.jpg", strings.NewReader("jpeg"), 4, "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if len(*requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.method != http.MethodPut || req.path != "/images/products/1/a%20b.jpg" {
+		t.Errorf("got %s %s, want PUT /images/products/1/a%%20b.jpg", req.method, req.path)
+	}
+	if req.body != "jpeg" {
+		t.Errorf("body = %q, want %q", req.body, "jpeg")
+	}
+	if got := req.header.Get("Content-Type"); got != "image/jpeg" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := req.header.Get("x-amz-date"); got != "20260102T030405Z" {
+		t.Errorf("x-amz-date = %q", got)
+	}
+	if got := req.header.Get("x-amz-content-sha256"); got != "UNSIGNED-PAYLOAD" {
+		t.Errorf("x-amz-content-sha256 = %q", got)
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	canonicalRequest := "PUT\n" +
+		"/images/products/1/a%20b.jpg\n" +
+		"\n" +
+		"host:" + host + "\n" +
+		"x-amz-content-sha256:UNSIGNED-PAYLOAD\n" +
+		"x-amz-date:20260102T030405Z\n" +
+		"\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" +
+		"UNSIGNED-PAYLOAD"
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260102/us-east-1/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" +
+		expectedSignature(s.cfg.SecretKey, "20260102T030405Z", "20260102/us-east-1/s3/aws4_request", canonicalRequest)
+	if got := req.header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestS3DeleteSignsRequest(t *testing.T) {
+	server, requests := newS3StandIn(t, http.StatusNoContent)
+	s := newTestS3(t, server.URL)
+
+	if err := s.Delete("products/1/a.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	req := (*requests)[0]
+	if req.method != http.MethodDelete || req.path != "/images/products/1/a.jpg" {
+		t.Errorf("got %s %s, want DELETE /images/products/1/a.jpg", req.method, req.path)
+	}
+	if !strings.HasPrefix(req.header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260102/us-east-1/s3/aws4_request") {
+		t.Errorf("Authorization = %q", req.header.Get("Authorization"))
+	}
+}
+
+func TestS3ErrorStatus(t *testing.T) {
+	server, _ := newS3StandIn(t, http.StatusForbidden)
+	s := newTestS3(t, server.URL)
+
+	if err := s.Put("a.jpg", strings.NewReader("x"), 1, ""); err == nil || !strings.Contains(err.Error(), "returned 403") {
+		t.Errorf("Put error = %v, want 403", err)
+	}
+}
+
+func TestS3URL(t *testing.T) {
+	s := newTestS3(t, "http://localhost:9000/")
+	if got := s.URL("a/b.jpg"); got != "http://localhost:9000/images/a/b.jpg" {
+		t.Errorf("URL = %q", got)
+	}
+
+	s.cfg.PublicURL = "https://cdn.example.com/"
+	if got := s.URL("a/b.jpg"); got != "https://cdn.example.com/a/b.jpg" {
+		t.Errorf("URL with public URL = %q", got)
+	}
+}
+
+// expectedSignature derives the SigV4 signature of a canonical request
+func expectedSignature(secret, amzDate, scope, canonicalRequest string) string {
+	parts := strings.Split(scope, "/")
+	hashed := sha256Hex(canonicalRequest)
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashed
+
+	key := []byte("AWS4" + secret)
+	for _, part := range parts {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
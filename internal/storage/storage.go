@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+)
+
+// Storage stores uploaded files under a key and exposes them through a public URL
+type Storage interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Delete(key string) error
+	URL(key string) string
+}
+
+type Config struct {
+	Driver    string
+	LocalDir  string
+	PublicURL string
+	S3        S3Config
+}
+
+// New returns the storage backend selected by cfg.Driver ("local" by default)
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalDir, cfg.PublicURL)
+	case "s3":
+		return NewS3Storage(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
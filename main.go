@@ -3,14 +3,19 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"gocats/internal/auth"
 	"gocats/internal/config"
 	"gocats/internal/database"
 	"gocats/internal/handlers"
+	"gocats/internal/receipt"
 	"gocats/internal/repository"
 	"gocats/internal/services"
+	"gocats/internal/storage"
 	"gocats/migrations"
 	"log"
+	"maps"
 	"net/http"
+	"strings"
 )
 
 func main() {
@@ -34,20 +39,88 @@ func main() {
 		log.Fatalf("Error running migrations: %v", err)
 	}
 
+	// Set up file storage for product images
+	fileStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalf("Error setting up storage: %v", err)
+	}
+
+	receiptRenderer, err := receipt.NewRenderer(cfg.Receipt)
+	if err != nil {
+		log.Fatalf("Error loading receipt templates: %v", err)
+	}
+
+	// Sign login tokens, JWT_SECRET is required
+	tokens, err := auth.NewTokens(cfg.Auth.Token)
+	if err != nil {
+		log.Fatalf("Error setting up login tokens: %v", err)
+	}
+
 	// initialize repositories
 	categoryRepo := repository.NewCategoryRepository(db.DB)
 	productRepo := repository.NewProductRepository(db.DB)
 	transactionRepo := repository.NewTransactionRepository(db.DB)
+	variantRepo := repository.NewVariantRepository(db.DB)
+	bundleRepo := repository.NewBundleRepository(db.DB)
+	imageRepo := repository.NewImageRepository(db.DB)
+	priceListRepo := repository.NewPriceListRepository(db.DB)
+	priceHistoryRepo := repository.NewPriceHistoryRepository(db.DB)
+	promotionRepo := repository.NewPromotionRepository(db.DB)
+	customerRepo := repository.NewCustomerRepository(db.DB)
+	customerGroupRepo := repository.NewCustomerGroupRepository(db.DB)
+	loyaltyRepo := repository.NewLoyaltyRepository(db.DB)
+	storedValueRepo := repository.NewStoredValueRepository(db.DB)
+	shiftRepo := repository.NewShiftRepository(db.DB)
+	dayReportRepo := repository.NewDayReportRepository(db.DB)
+	userRepo := repository.NewUserRepository(db.DB)
 
 	// initialize services
-	categoryService := services.NewCategoryService(categoryRepo)
-	productService := services.NewProductService(productRepo, categoryRepo)
-	transactionService := services.NewTransactionService(db.DB, transactionRepo, productRepo)
+	loyaltyRules := services.LoyaltyRules{
+		EarnRate:   cfg.Loyalty.EarnRate,
+		PointValue: cfg.Loyalty.PointValue,
+	}
+	taxRules := services.TaxRules{Rate: cfg.Tax.Rate}
+	invoiceRules := services.InvoiceRules{
+		Prefix:    cfg.Invoice.Prefix,
+		StoreCode: cfg.Invoice.StoreCode,
+		Reset:     cfg.Invoice.Reset,
+		Digits:    cfg.Invoice.Digits,
+		Separator: cfg.Invoice.Separator,
+	}
+	shiftRules := services.ShiftRules{Required: cfg.Shift.Required}
+	categoryService := services.NewCategoryService(db.DB, categoryRepo)
+	productService := services.NewProductService(productRepo, categoryRepo, variantRepo, bundleRepo, priceListRepo, priceHistoryRepo, customerGroupRepo)
+	transactionService := services.NewTransactionService(db.DB, transactionRepo, productRepo, categoryRepo, priceListRepo, promotionRepo, customerGroupRepo, loyaltyRepo, storedValueRepo, shiftRepo, loyaltyRules, taxRules, invoiceRules, shiftRules)
+	imageService := services.NewImageService(imageRepo, productRepo, fileStorage)
+	priceListService := services.NewPriceListService(priceListRepo, productRepo, categoryRepo)
+	promotionService := services.NewPromotionService(promotionRepo, productRepo, categoryRepo)
+	customerService := services.NewCustomerService(customerRepo, customerGroupRepo)
+	customerGroupService := services.NewCustomerGroupService(customerGroupRepo, productRepo)
+	loyaltyService := services.NewLoyaltyService(db.DB, loyaltyRepo, customerRepo, loyaltyRules)
+	storedValueService := services.NewStoredValueService(db.DB, storedValueRepo, customerRepo)
+	shiftService := services.NewShiftService(db.DB, shiftRepo)
+	reportService := services.NewReportService(db.DB, dayReportRepo, receiptRenderer)
+	receiptService := services.NewReceiptService(transactionRepo, receiptRenderer, receipt.NewPrinter(cfg.Printer))
+	userService := services.NewUserService(userRepo, tokens)
+
+	if err := userService.EnsureAdmin(cfg.Auth.AdminUsername, cfg.Auth.AdminPassword); err != nil {
+		log.Fatalf("Error creating admin user: %v", err)
+	}
 
 	// initialize HTTP Handlers
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
 	productHandler := handlers.NewProductHandler(productService)
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	imageHandler := handlers.NewImageHandler(imageService)
+	priceListHandler := handlers.NewPriceListHandler(priceListService)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+	customerHandler := handlers.NewCustomerHandler(customerService, loyaltyService)
+	customerGroupHandler := handlers.NewCustomerGroupHandler(customerGroupService)
+	storedValueHandler := handlers.NewStoredValueHandler(storedValueService)
+	receiptHandler := handlers.NewReceiptHandler(receiptService)
+	shiftHandler := handlers.NewShiftHandler(shiftService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	userHandler := handlers.NewUserHandler(userService)
 
 	// setup routes
 	// health check endpoint
@@ -70,6 +143,16 @@ func main() {
 
 	})
 
+	// Serve locally stored uploads under the path of their public URL
+	routes := handlers.RoutePermissions
+	if local, ok := fileStorage.(*storage.LocalStorage); ok {
+		if route := local.Route(); route != "" {
+			http.Handle(route, http.StripPrefix(route, local.Handler()))
+			routes = maps.Clone(routes)
+			routes[route] = auth.Public
+		}
+	}
+
 	http.HandleFunc("/api/categories", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
@@ -82,6 +165,54 @@ func main() {
 	})
 
 	http.HandleFunc("/api/categories/", func(w http.ResponseWriter, r *http.Request) {
+		// Category tree: /api/categories/tree and subtree: /api/categories/{id}/tree
+		if strings.HasSuffix(r.URL.Path, "/tree") {
+			switch {
+			case r.Method != http.MethodGet:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			case r.URL.Path == "/api/categories/tree":
+				categoryHandler.GetCategoryTree(w, r)
+			default:
+				categoryHandler.GetCategorySubtree(w, r)
+			}
+			return
+		}
+
+		// Merge categories into this one: /api/categories/{id}/merge
+		if strings.HasSuffix(r.URL.Path, "/merge") {
+			switch r.Method {
+			case http.MethodGet:
+				categoryHandler.GetCategoryMerges(w, r)
+			case http.MethodPost:
+				categoryHandler.MergeCategories(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Restore an archived category: /api/categories/{id}/restore
+		if strings.HasSuffix(r.URL.Path, "/restore") {
+			switch r.Method {
+			case http.MethodPost:
+				categoryHandler.RestoreCategory(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Category attribute schema: /api/categories/{id}/attributes
+		if strings.HasSuffix(r.URL.Path, "/attributes") {
+			switch r.Method {
+			case http.MethodPut:
+				categoryHandler.SetAttributeSchema(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
 		switch r.Method {
 		case http.MethodGet:
 			categoryHandler.GetCategoryByID(w, r)
@@ -98,12 +229,7 @@ func main() {
 	http.HandleFunc("/api/products", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			// Check if filtering by category
-			if r.URL.Query().Get("category_id") != "" {
-				productHandler.GetProductsByCategoryID(w, r)
-			} else {
-				productHandler.GetAllProducts(w, r)
-			}
+			productHandler.GetAllProducts(w, r)
 		case http.MethodPost:
 			productHandler.CreateProduct(w, r)
 		default:
@@ -112,6 +238,95 @@ func main() {
 	})
 
 	http.HandleFunc("/api/products/", func(w http.ResponseWriter, r *http.Request) {
+		subPath := strings.TrimPrefix(r.URL.Path, "/api/products/")
+
+		// Ranked, typo-tolerant search: /api/products/search?q=term
+		if subPath == "search" {
+			switch r.Method {
+			case http.MethodGet:
+				productHandler.SearchProducts(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Restore an archived product: /api/products/{id}/restore
+		if strings.HasSuffix(subPath, "/restore") {
+			switch r.Method {
+			case http.MethodPost:
+				productHandler.RestoreProduct(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Product option types: /api/products/{id}/options
+		if strings.HasSuffix(subPath, "/options") {
+			switch r.Method {
+			case http.MethodPost:
+				productHandler.SaveProductOption(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Price history: /api/products/{id}/price-history
+		if strings.HasSuffix(subPath, "/price-history") {
+			switch r.Method {
+			case http.MethodGet:
+				productHandler.GetPriceHistory(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Bundle components: /api/products/{id}/components
+		if strings.HasSuffix(subPath, "/components") {
+			switch r.Method {
+			case http.MethodPut:
+				productHandler.SetBundleComponents(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Product images: /api/products/{id}/images[/{image_id}]
+		if strings.Contains(subPath, "/images") {
+			switch r.Method {
+			case http.MethodGet:
+				imageHandler.GetImages(w, r)
+			case http.MethodPost:
+				imageHandler.UploadImage(w, r)
+			case http.MethodPut:
+				imageHandler.ReorderImages(w, r)
+			case http.MethodDelete:
+				imageHandler.DeleteImage(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Product variants: /api/products/{id}/variants[/{variant_id}]
+		if strings.Contains(subPath, "/variants") {
+			switch r.Method {
+			case http.MethodPost:
+				productHandler.AddVariant(w, r)
+			case http.MethodPut:
+				productHandler.UpdateVariant(w, r)
+			case http.MethodDelete:
+				productHandler.DeleteVariant(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
 		switch r.Method {
 		case http.MethodGet:
 			productHandler.GetProductByID(w, r)
@@ -124,6 +339,225 @@ func main() {
 		}
 	})
 
+	// Price list routes
+	http.HandleFunc("/api/price-lists", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			priceListHandler.GetPriceLists(w, r)
+		case http.MethodPost:
+			priceListHandler.CreatePriceList(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/price-lists/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			priceListHandler.GetPriceListByID(w, r)
+		case http.MethodPut:
+			priceListHandler.UpdatePriceList(w, r)
+		case http.MethodDelete:
+			priceListHandler.DeletePriceList(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Promotion routes
+	http.HandleFunc("/api/promotions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			promotionHandler.GetAllPromotions(w, r)
+		case http.MethodPost:
+			promotionHandler.CreatePromotion(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/promotions/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			promotionHandler.GetPromotionByID(w, r)
+		case http.MethodPut:
+			promotionHandler.UpdatePromotion(w, r)
+		case http.MethodDelete:
+			promotionHandler.DeletePromotion(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Shift routes
+	http.HandleFunc("/api/shifts", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			shiftHandler.GetAllShifts(w, r)
+		case http.MethodPost:
+			shiftHandler.OpenShift(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/shifts/", func(w http.ResponseWriter, r *http.Request) {
+		// Open shift of the cashier: /api/shifts/current
+		if r.URL.Path == "/api/shifts/current" {
+			switch r.Method {
+			case http.MethodGet:
+				shiftHandler.GetCurrentShift(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Pay-ins and pay-outs: /api/shifts/{id}/movements
+		if strings.HasSuffix(r.URL.Path, "/movements") {
+			switch r.Method {
+			case http.MethodPost:
+				shiftHandler.RecordCashMovement(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Close with the counted cash: /api/shifts/{id}/close
+		if strings.HasSuffix(r.URL.Path, "/close") {
+			switch r.Method {
+			case http.MethodPost:
+				shiftHandler.CloseShift(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Cash variance report: /api/shifts/{id}/report
+		if strings.HasSuffix(r.URL.Path, "/report") {
+			switch r.Method {
+			case http.MethodGet:
+				shiftHandler.GetShiftReport(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			shiftHandler.GetShiftByID(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Gift card routes
+	http.HandleFunc("/api/gift-cards", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			storedValueHandler.IssueGiftCard(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/gift-cards/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			storedValueHandler.GetGiftCard(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Customer group routes
+	http.HandleFunc("/api/customer-groups", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			customerGroupHandler.GetCustomerGroups(w, r)
+		case http.MethodPost:
+			customerGroupHandler.CreateCustomerGroup(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/customer-groups/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			customerGroupHandler.GetCustomerGroupByID(w, r)
+		case http.MethodPut:
+			customerGroupHandler.UpdateCustomerGroup(w, r)
+		case http.MethodDelete:
+			customerGroupHandler.DeleteCustomerGroup(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Customer routes
+	http.HandleFunc("/api/customers", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			customerHandler.GetAllCustomers(w, r)
+		case http.MethodPost:
+			customerHandler.CreateCustomer(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/customers/", func(w http.ResponseWriter, r *http.Request) {
+		// Store credit balance and adjustments: /api/customers/{id}/store-credit
+		if strings.HasSuffix(r.URL.Path, "/store-credit") {
+			switch r.Method {
+			case http.MethodGet:
+				storedValueHandler.GetStoreCredit(w, r)
+			case http.MethodPost:
+				storedValueHandler.AdjustStoreCredit(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Loyalty points balance: /api/customers/{id}/points
+		if strings.HasSuffix(r.URL.Path, "/points") {
+			switch r.Method {
+			case http.MethodGet:
+				customerHandler.GetLoyaltyBalance(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Purchase history: /api/customers/{id}/purchases
+		if strings.HasSuffix(r.URL.Path, "/purchases") {
+			switch r.Method {
+			case http.MethodGet:
+				customerHandler.GetPurchaseHistory(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			customerHandler.GetCustomerByID(w, r)
+		case http.MethodPut:
+			customerHandler.UpdateCustomer(w, r)
+		case http.MethodDelete:
+			customerHandler.DeleteCustomer(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Transaction routes
 	http.HandleFunc("/api/checkout", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -134,6 +568,67 @@ func main() {
 		}
 	})
 
+	http.HandleFunc("/api/transactions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			transactionHandler.GetAllTransactions(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/transactions/", func(w http.ResponseWriter, r *http.Request) {
+		// Printable receipt: /api/transactions/{id}/receipt
+		if strings.HasSuffix(r.URL.Path, "/receipt") {
+			switch r.Method {
+			case http.MethodGet:
+				receiptHandler.GetReceipt(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Send the receipt to the network printer: /api/transactions/{id}/print
+		if strings.HasSuffix(r.URL.Path, "/print") {
+			switch r.Method {
+			case http.MethodPost:
+				receiptHandler.PrintReceipt(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// Refund a transaction: /api/transactions/{id}/refund
+		if strings.HasSuffix(r.URL.Path, "/refund") {
+			switch r.Method {
+			case http.MethodPost:
+				transactionHandler.RefundTransaction(w, r)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			transactionHandler.GetTransactionByID(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Dry-run pricing with promotions applied
+	http.HandleFunc("/api/checkout/preview", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			transactionHandler.PreviewCheckout(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Report routes
 	http.HandleFunc("/api/report/today", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -144,6 +639,73 @@ func main() {
 		}
 	})
 
+	http.HandleFunc("/api/report/bundles", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			transactionHandler.GetBundleSales(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/report/price-changes", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			productHandler.GetPriceChanges(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/report/components", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			transactionHandler.GetComponentSales(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/report/departments", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			transactionHandler.GetDepartmentSales(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// X-report of the business day so far
+	http.HandleFunc("/api/report/x", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			reportHandler.GetXReport(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Z-reports close the business day and are kept for reprints
+	http.HandleFunc("/api/report/z", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			reportHandler.GetAllZReports(w, r)
+		case http.MethodPost:
+			reportHandler.CloseDay(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/report/z/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			reportHandler.GetZReport(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
 	http.HandleFunc("/api/report", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
@@ -153,10 +715,67 @@ func main() {
 		}
 	})
 
+	// Auth routes
+	http.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			userHandler.Login(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/auth/me", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			userHandler.Me(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// User routes
+	http.HandleFunc("/api/users", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			userHandler.GetAllUsers(w, r)
+		case http.MethodPost:
+			userHandler.CreateUser(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/api/users/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			userHandler.GetUserByID(w, r)
+		case http.MethodPut:
+			userHandler.UpdateUser(w, r)
+		case http.MethodDelete:
+			userHandler.DeleteUser(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Accept login tokens and, when configured, Supabase Auth tokens
+	verifiers := auth.Verifiers{userService}
+	if cfg.Auth.Supabase.Enabled() {
+		supabase, err := auth.NewSupabaseVerifier(cfg.Auth.Supabase)
+		if err != nil {
+			log.Fatalf("Error setting up Supabase Auth: %v", err)
+		}
+		verifiers = append(verifiers, supabase)
+	}
+
+	// Every route needs a bearer token with the permission listed in handlers.RoutePermissions
+	handler := auth.Middleware(verifiers, auth.NewPolicy(routes), http.DefaultServeMux)
+
 	// Start server
 	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
 	log.Printf("🚀 Server starting on %s...", addr)
-	if err := http.ListenAndServe(":"+cfg.Server.Port, nil); err != nil {
+	if err := http.ListenAndServe(":"+cfg.Server.Port, handler); err != nil {
 		log.Fatalf("❌ HTTP server failed: %v", err)
 	}
 
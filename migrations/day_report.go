@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// dayReportStatements make stored Z-reports immutable: they can be added but
+// never updated or deleted
+var dayReportStatements = []string{
+	`CREATE OR REPLACE FUNCTION reject_day_report_change() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'Z-reports cannot be changed or deleted';
+	END;
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS day_reports_immutable ON day_reports`,
+	`CREATE TRIGGER day_reports_immutable BEFORE UPDATE OR DELETE ON day_reports
+		FOR EACH ROW EXECUTE FUNCTION reject_day_report_change()`,
+}
+
+func protectDayReports(db *gorm.DB) error {
+	for _, stmt := range dayReportStatements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("day report protection failed: %w", err)
+		}
+	}
+	return nil
+}
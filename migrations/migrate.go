@@ -12,10 +12,33 @@ func RunMigrations(db *database.DB) error {
 
 	// List of models to migrate
 	models := []interface{}{
-		&models.Category{},          // Ensure Category is migrated before Product
-		&models.Product{},           // Has a foreign key to Category
-		&models.Transaction{},       // Transaction table
-		&models.TransactionDetail{}, // Has foreign keys to Transaction and Product
+		&models.Category{},                   // Ensure Category is migrated before Product
+		&models.CategoryMerge{},              // History of category merges
+		&models.Product{},                    // Has a foreign key to Category
+		&models.ProductOption{},              // Option types of a parent product
+		&models.ProductVariant{},             // Sellable variants of a parent product
+		&models.BundleItem{},                 // Components of bundle products
+		&models.ProductImage{},               // Product photos
+		&models.PriceList{},                  // Scheduled and promotional prices
+		&models.PriceListEntry{},             // Product and category prices of a price list
+		&models.PriceChange{},                // Price change history
+		&models.Promotion{},                  // Checkout promotion rules
+		&models.CustomerGroup{},              // Customer groups with their own prices
+		&models.PriceTier{},                  // Quantity price tiers of a customer group
+		&models.Customer{},                   // Customers attached to transactions
+		&models.Transaction{},                // Transaction table
+		&models.TransactionDetail{},          // Has foreign keys to Transaction and Product
+		&models.TransactionBundleComponent{}, // Component stock consumed by bundle lines
+		&models.Refund{},                     // Refunded transactions
+		&models.LoyaltyEntry{},               // Loyalty points ledger
+		&models.StoredValueAccount{},         // Gift cards and store credit
+		&models.StoredValueEntry{},           // Gift card and store credit ledger
+		&models.TransactionTender{},          // Gift card and store credit payments
+		&models.InvoiceSequence{},            // Invoice number sequences
+		&models.Shift{},                      // Cashier shifts
+		&models.CashMovement{},               // Pay-ins and pay-outs of a shift
+		&models.DayReport{},                  // Stored Z-reports
+		&models.User{},                       // Staff accounts and roles
 	}
 
 	if err := migrator.AutoMigrate(models...); err != nil {
@@ -24,11 +47,19 @@ func RunMigrations(db *database.DB) error {
 		msg := err.Error()
 		if strings.Contains(msg, "already exists") || strings.Contains(msg, "stmtcache") {
 			log.Printf("Migration warning (ignored): %v", err)
-			return nil
+		} else {
+			log.Printf("Migration error (non-fatal): %v", err)
 		}
+	}
+
+	// Product search queries the search_vector column, so it must exist
+	if err := createSearchIndexes(db.DB); err != nil {
+		return err
+	}
 
-		log.Printf("Migration error (non-fatal): %v", err)
-		return nil
+	// Z-reports are immutable only while the trigger is installed
+	if err := protectDayReports(db.DB); err != nil {
+		return err
 	}
 
 	log.Println("All Migrations completed")
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// searchStatements set up full-text and trigram search on products. The
+// search vector is a generated column so it never goes stale; SKUs and
+// category names live in other tables and get trigram indexes of their own.
+var searchStatements = []string{
+	`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+	`ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+			setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+		) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_product_variants_sku_trgm ON product_variants USING GIN (sku gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_categories_name_trgm ON categories USING GIN (name gin_trgm_ops)`,
+}
+
+func createSearchIndexes(db *gorm.DB) error {
+	for _, stmt := range searchStatements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("search index setup failed: %w", err)
+		}
+	}
+	return nil
+}